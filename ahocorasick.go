@@ -0,0 +1,110 @@
+package bench
+
+// Aho-Corasick automaton, for finding any of a set of words in one pass. It
+// generalizes the KMP table from kmpBuildTable: the failure link of a state is
+// the longest proper suffix of its path that is also a path in the trie of
+// words, just as T[i] is the longest proper border of word[:i]. Here the
+// failure links are folded into a complete transition table, so searching
+// never has to follow them.
+// via: http://en.wikipedia.org/wiki/Aho–Corasick_algorithm
+type ahoCorasick struct {
+	// bytes that appear in no word all share class 0, which keeps the
+	// transition table down to one row of numClasses entries per state
+	classes    [256]int32
+	numClasses int
+
+	delta []int32   // delta[state*numClasses+class] is the next state
+	out   [][]int32 // indexes of the words that end at each state
+	lens  []int     // length of each word
+}
+
+// acSearch stores every occurrence of any word in line into result, ordered
+// by where the occurrences end.
+func acSearch(ac *ahoCorasick, line []byte, result []hit) []hit {
+	// "empty" the initial result by setting its length to zero
+	result = result[0:0]
+
+	state := int32(0)
+	for i, b := range line {
+		state = ac.delta[int(state)*ac.numClasses+int(ac.classes[b])]
+
+		for _, p := range ac.out[state] {
+			result = append(result, hit{start: i + 1 - ac.lens[p], end: i + 1, pattern: int(p)})
+		}
+	}
+
+	return result
+}
+
+// builds the Aho-Corasick automaton for words
+func acBuild(words [][]byte) *ahoCorasick {
+	ac := &ahoCorasick{numClasses: 1, lens: make([]int, len(words))}
+
+	for _, word := range words {
+		for _, b := range word {
+			if ac.classes[b] == 0 {
+				ac.classes[b] = int32(ac.numClasses)
+				ac.numClasses++
+			}
+		}
+	}
+
+	// the trie of words, with -1 for missing edges; state 0 is the root
+	newState := func() int32 {
+		for c := 0; c < ac.numClasses; c++ {
+			ac.delta = append(ac.delta, -1)
+		}
+		ac.out = append(ac.out, nil)
+		return int32(len(ac.out) - 1)
+	}
+	newState()
+
+	for p, word := range words {
+		state := int32(0)
+		for _, b := range word {
+			i := int(state)*ac.numClasses + int(ac.classes[b])
+			if ac.delta[i] < 0 {
+				// newState grows delta, so keep the index rather than a pointer
+				next := newState()
+				ac.delta[i] = next
+			}
+			state = ac.delta[i]
+		}
+		ac.out[state] = append(ac.out[state], int32(p))
+		ac.lens[p] = len(word)
+	}
+
+	// breadth first, so that the failure state of every state is complete
+	// before the state itself is
+	fail := make([]int32, len(ac.out))
+	queue := make([]int32, 0, len(ac.out))
+
+	for c := 0; c < ac.numClasses; c++ {
+		if next := ac.delta[c]; next < 0 {
+			ac.delta[c] = 0
+		} else {
+			queue = append(queue, next)
+		}
+	}
+
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+
+		// words ending at the failure state end here too, and are shorter
+		ac.out[state] = append(ac.out[state], ac.out[fail[state]]...)
+
+		row := int(state) * ac.numClasses
+		failRow := int(fail[state]) * ac.numClasses
+		for c := 0; c < ac.numClasses; c++ {
+			if next := ac.delta[row+c]; next < 0 {
+				ac.delta[row+c] = ac.delta[failRow+c]
+			} else {
+				fail[next] = ac.delta[failRow+c]
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return ac
+}
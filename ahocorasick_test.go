@@ -0,0 +1,209 @@
+package bench
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+)
+
+// naiveSearchAll is the reference for searching several words at once: every
+// occurrence ordered by start and then by preference, with the overlapping
+// ones removed unless overlap is set.
+func naiveSearchAll(words []string, line string, overlap bool, leftmost Leftmost) []hit {
+	var hits []hit
+	for start := 0; start < len(line); start++ {
+		var here []hit
+		for p, word := range words {
+			if strings.HasPrefix(line[start:], word) {
+				here = append(here, hit{start: start, end: start + len(word), pattern: p})
+			}
+		}
+		if leftmost == LeftmostLongest {
+			// stable, so that equal lengths stay in pattern order
+			for i := 1; i < len(here); i++ {
+				for j := i; j > 0 && here[j].end > here[j-1].end; j-- {
+					here[j], here[j-1] = here[j-1], here[j]
+				}
+			}
+		}
+		hits = append(hits, here...)
+	}
+
+	if overlap {
+		return hits
+	}
+
+	var result []hit
+	nextCol := 0
+	for _, h := range hits {
+		if h.start >= nextCol {
+			result = append(result, h)
+			nextCol = h.end
+		}
+	}
+
+	return result
+}
+
+func TestFindAll(t *testing.T) {
+	got, err := FindAll(path, []string{"aa", "ee", "aab"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Match{
+		{Row: 1, Col: 0, Offset: 0, Len: 2, Pattern: 0, EndRow: 1, EndCol: 2},
+		{Row: 1, Col: 0, Offset: 0, Len: 3, Pattern: 2, EndRow: 1, EndCol: 3},
+		{Row: 1, Col: 8, Offset: 8, Len: 2, Pattern: 1, EndRow: 1, EndCol: 10},
+		{Row: 1, Col: 10, Offset: 10, Len: 2, Pattern: 0, EndRow: 1, EndCol: 12},
+		{Row: 1, Col: 10, Offset: 10, Len: 3, Pattern: 2, EndRow: 1, EndCol: 13},
+		{Row: 1, Col: 18, Offset: 18, Len: 2, Pattern: 1, EndRow: 1, EndCol: 20},
+		{Row: 6, Col: 0, Offset: 105, Len: 2, Pattern: 0, EndRow: 6, EndCol: 2},
+		{Row: 6, Col: 1, Offset: 106, Len: 2, Pattern: 0, EndRow: 6, EndCol: 3},
+		{Row: 6, Col: 1, Offset: 106, Len: 3, Pattern: 2, EndRow: 6, EndCol: 4},
+		{Row: 6, Col: 12, Offset: 117, Len: 2, Pattern: 1, EndRow: 6, EndCol: 14},
+		{Row: 6, Col: 13, Offset: 118, Len: 2, Pattern: 1, EndRow: 6, EndCol: 15},
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("FindAll => %v, want %v", got, expected)
+	}
+
+	for i, m := range got {
+		if m != expected[i] {
+			t.Errorf("FindAll[%d] => %+v, want %+v", i, m, expected[i])
+		}
+	}
+}
+
+func TestFindAll_emptyPattern(t *testing.T) {
+	if _, err := FindAll(path, nil); err == nil {
+		t.Error("some kind of error should be returned")
+	}
+	if _, err := FindAll(path, []string{"aa", ""}); err == nil {
+		t.Error("some kind of error should be returned")
+	}
+}
+
+func TestCompileAll_leftmost(t *testing.T) {
+	for _, tc := range []struct {
+		overlap  bool
+		leftmost Leftmost
+		want     string
+	}{
+		{true, LeftmostFirst, "1:0:0,1:0:1,1:1:2,1:3:0,1:3:1,1:4:2"},
+		{false, LeftmostFirst, "1:0:0,1:3:0"},
+		{false, LeftmostLongest, "1:0:1,1:3:1"},
+	} {
+		opts := DefaultOptions()
+		opts.Overlap = tc.overlap
+		opts.Leftmost = tc.leftmost
+
+		m, err := CompileAllWithOptions([]string{"ab", "abc", "bc"}, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, match := range m.FindBytes([]byte("abcabc")) {
+			got = append(got, match.String()+":"+string(rune('0'+match.Pattern)))
+		}
+		if s := strings.Join(got, ","); s != tc.want {
+			t.Errorf("overlap %v, leftmost %v => %q, want %q", tc.overlap, tc.leftmost, s, tc.want)
+		}
+	}
+}
+
+func TestCompileAll_random(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for _, alphabet := range []string{"ab", "abc"} {
+		for n := 0; n < 200; n++ {
+			words := make([]string, 1+rnd.Intn(5))
+			for i := range words {
+				word := make([]byte, 1+rnd.Intn(4))
+				for j := range word {
+					word[j] = alphabet[rnd.Intn(len(alphabet))]
+				}
+				words[i] = string(word)
+			}
+			line := make([]byte, rnd.Intn(32))
+			for i := range line {
+				line[i] = alphabet[rnd.Intn(len(alphabet))]
+			}
+
+			for _, overlap := range []bool{true, false} {
+				for _, leftmost := range []Leftmost{LeftmostFirst, LeftmostLongest} {
+					opts := DefaultOptions()
+					opts.Overlap = overlap
+					opts.Leftmost = leftmost
+					m, err := CompileAllWithOptions(words, opts)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					expected := naiveSearchAll(words, string(line), overlap, leftmost)
+					got := m.FindBytes(line)
+					if len(got) != len(expected) {
+						t.Fatalf("%q in %q (overlap %v, leftmost %v) => %v, want %v", words, line, overlap, leftmost, got, expected)
+					}
+					for i, match := range got {
+						h := expected[i]
+						if match.Col != h.start || match.Len != h.end-h.start || match.Pattern != h.pattern {
+							t.Fatalf("%q in %q (overlap %v, leftmost %v) => %v, want %v", words, line, overlap, leftmost, got, expected)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_acBuild_sharedSuffix(t *testing.T) {
+	// "he" is a suffix of "she", so it has to be reported at the "she" state too
+	words := [][]byte{[]byte("he"), []byte("she"), []byte("his"), []byte("hers")}
+	ac := acBuild(words)
+
+	got := acSearch(ac, []byte("ushers"), nil)
+	expected := []hit{{start: 1, end: 4, pattern: 1}, {start: 2, end: 4, pattern: 0}, {start: 2, end: 6, pattern: 3}}
+
+	if len(got) != len(expected) {
+		t.Fatalf("acSearch => %v, want %v", got, expected)
+	}
+	for i, h := range got {
+		if h != expected[i] {
+			t.Errorf("acSearch => %v, want %v", got, expected)
+			break
+		}
+	}
+}
+
+func BenchmarkFindAll(b *testing.B) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	patterns := strings.Fields("aa ee ff jj kk oo pp tt uu yy xyz aaa")
+
+	b.Run("AhoCorasick", func(b *testing.B) {
+		m, _ := CompileAll(patterns)
+		for i := 0; i < b.N; i++ {
+			m.FindReader(bytes.NewReader(data))
+		}
+	})
+
+	b.Run("KMP", func(b *testing.B) {
+		var ms []*Matcher
+		for _, p := range patterns {
+			m, _ := Compile(p)
+			ms = append(ms, m)
+		}
+		for i := 0; i < b.N; i++ {
+			for _, m := range ms {
+				m.FindReader(bytes.NewReader(data))
+			}
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package bench
+
+import "fmt"
+
+// chooseAlgorithm picks the backend Auto uses for words, and says why. It
+// only looks at the words, since nothing is known about the input until the
+// search starts.
+func chooseAlgorithm(words [][]byte) (Algorithm, string) {
+	if len(words) > 1 {
+		return chooseMultiAlgorithm(words)
+	}
+
+	word := words[0]
+	n := len(word)
+
+	if n == 1 {
+		return KMP, "single byte word, nothing to skip"
+	}
+	if n > 4096 {
+		return TwoWay, fmt.Sprintf("long word (%d bytes) needs constant extra memory", n)
+	}
+
+	if period := kmpPeriod(word, kmpBuildTable(string(word))); period <= n/2 {
+		return TwoWay, fmt.Sprintf("periodic word (period %d of %d bytes), skipping would degrade", period, n)
+	}
+
+	distinct := 0
+	var seen [256]bool
+	for _, b := range word {
+		if !seen[b] {
+			seen[b] = true
+			distinct++
+		}
+	}
+	if n < 3 || distinct < 3 {
+		return KMP, fmt.Sprintf("short word or few distinct bytes (%d of %d), skips would be small", distinct, n)
+	}
+
+	if n < 16 {
+		return Horspool, fmt.Sprintf("short word (%d bytes) with %d distinct bytes, the bad-character skip pays off", n, distinct)
+	}
+
+	return BoyerMoore, fmt.Sprintf("word of %d bytes with %d distinct bytes, skipping pays off and the good-suffix rule keeps the worst case linear", n, distinct)
+}
+
+// acMaxTable is the largest Aho-Corasick transition table Auto accepts when
+// the words all have the same length and Rabin-Karp could be used instead.
+const acMaxTable = 1 << 20
+
+// chooseMultiAlgorithm is chooseAlgorithm for more than one word.
+func chooseMultiAlgorithm(words [][]byte) (Algorithm, string) {
+	total := 0
+	var seen [256]bool
+	classes := 1
+	sameLength := true
+
+	for _, word := range words {
+		total += len(word)
+		sameLength = sameLength && len(word) == len(words[0])
+		for _, b := range word {
+			if !seen[b] {
+				seen[b] = true
+				classes++
+			}
+		}
+	}
+
+	// the trie has at most one state per byte of the words, each with a row
+	// of 4 byte entries, one per byte class
+	table := (total + 1) * classes * 4
+	if sameLength && table > acMaxTable {
+		return RabinKarp, fmt.Sprintf("%d words of the same length, Aho-Corasick table could take %d bytes", len(words), table)
+	}
+
+	return AhoCorasick, fmt.Sprintf("%d words, Aho-Corasick table takes at most %d bytes", len(words), table)
+}
+
+// kmpPeriod returns the smallest period of word, from its KMP table T: the
+// length of the word less its longest proper border.
+func kmpPeriod(word []byte, T []int) int {
+	n := len(word)
+
+	// extend the table by one, to the border of the whole word
+	b := T[n-1]
+	for b >= 0 && word[b] != word[n-1] {
+		b = T[b]
+	}
+
+	return n - (b + 1)
+}
@@ -0,0 +1,153 @@
+package bench
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAuto(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Algorithm = Auto
+
+	for _, tc := range []struct {
+		patterns []string
+		want     Algorithm
+	}{
+		{[]string{"a"}, KMP},
+		{[]string{"aa"}, TwoWay},
+		{[]string{"abababab"}, TwoWay},
+		{[]string{"ab"}, KMP},
+		{[]string{"aab"}, KMP},
+		{[]string{"info"}, Horspool},
+		{[]string{"123e4567-e89b-12d3-a456-426614174000"}, BoyerMoore},
+		{[]string{strings.Repeat("0123456789", 500)}, TwoWay},
+		{[]string{"aa", "ee"}, AhoCorasick},
+		{equalLengthPatterns(), AhoCorasick},
+		{manyIDs(20000), RabinKarp},
+	} {
+		m, err := CompileAllWithOptions(tc.patterns, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Algorithm() != tc.want {
+			t.Errorf("Auto picked %v for %.20q (%s), want %v", m.Algorithm(), tc.patterns, m.Reason(), tc.want)
+		}
+		if m.Reason() == "" {
+			t.Errorf("Auto gave no reason for %v", m.Algorithm())
+		}
+	}
+}
+
+func TestMatcher_Algorithm(t *testing.T) {
+	m, err := CompileAll([]string{"aa", "ee"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Algorithm() != AhoCorasick {
+		t.Errorf("Algorithm() => %v, want %v", m.Algorithm(), AhoCorasick)
+	}
+}
+
+func Test_kmpPeriod(t *testing.T) {
+	for _, tc := range []struct {
+		W      string
+		period int
+	}{
+		{"a", 1},
+		{"aa", 1},
+		{"ABCDABD", 7},
+		{"abcab", 3},
+		{"abab", 2},
+		{"PARTICIPATE IN PARACHUTE", 24},
+	} {
+		if got := kmpPeriod([]byte(tc.W), kmpBuildTable(tc.W)); got != tc.period {
+			t.Errorf("kmpPeriod(%q) => %d, want %d", tc.W, got, tc.period)
+		}
+	}
+}
+
+// manyIDs returns n different 8 byte hex IDs.
+func manyIDs(n int) []string {
+	rnd := rand.New(rand.NewSource(1))
+	ids := make([]string, n)
+	for i := range ids {
+		const hex = "0123456789abcdef"
+		id := make([]byte, 8)
+		for j := range id {
+			id[j] = hex[rnd.Intn(len(hex))]
+		}
+		ids[i] = string(id)
+	}
+
+	return ids
+}
+
+// logText returns about 1MB of log-like lines made of a small vocabulary.
+func logText() []byte {
+	rnd := rand.New(rand.NewSource(1))
+	vocab := strings.Fields("the quick brown fox jumps over lazy dog error warning info request id user session timeout connection refused 0f3a9c12 lorem ipsum dolor sit amet")
+
+	var buf bytes.Buffer
+	for buf.Len() < 1<<20 {
+		for n := 5 + rnd.Intn(15); n > 0; n-- {
+			buf.WriteString(vocab[rnd.Intn(len(vocab))])
+			buf.WriteByte(' ')
+		}
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes()
+}
+
+// BenchmarkAuto runs every backend over a range of words and inputs, so that
+// Auto can be compared with the best fixed choice for each.
+func BenchmarkAuto(b *testing.B) {
+	large, err := os.ReadFile(pathLarge)
+	if err != nil {
+		b.Fatal(err)
+	}
+	text := logText()
+
+	// long lines of "ab" with the odd "c"
+	periodic := bytes.Repeat([]byte("ab"), 1<<19)
+	for i := 1000; i < len(periodic); i += 1000 {
+		periodic[i] = 'c'
+	}
+	for i := 4000; i < len(periodic); i += 4000 {
+		periodic[i] = '\n'
+	}
+
+	for _, bc := range []struct {
+		name string
+		word string
+		data []byte
+	}{
+		{"large", word, large},
+		{"byte", "e", text},
+		{"short", "to", text},
+		{"word", "sessi", text},
+		{"uuid", "123e4567-e89b-12d3-a456-426614174000", text},
+		{"phrase", "connection refused timeout", text},
+		{"periodic", strings.Repeat("ab", 32), periodic},
+		{"long", string(text[5000:9096]), text},
+	} {
+		for _, algo := range []Algorithm{Auto, KMP, Horspool, BoyerMoore, TwoWay, AhoCorasick, RabinKarp} {
+			opts := DefaultOptions()
+			opts.Algorithm = algo
+			m, err := CompileWithOptions(bc.word, opts)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.Run(bc.name+"/"+algo.String(), func(b *testing.B) {
+				b.SetBytes(int64(len(bc.data)))
+				for i := 0; i < b.N; i++ {
+					m.FindFunc(bytes.NewReader(bc.data), func(Match) bool { return true })
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package bench
+
+import "container/heap"
+
+// bestMatches keeps the n closest matches seen so far, for Options.Best. It is
+// a heap with the worst of them on top, so that it can be replaced as soon as
+// a closer match comes along.
+type bestMatches struct {
+	n       int
+	matches []Match
+}
+
+// worse reports whether a ranks below b: more errors, or the same number of
+// errors but found later.
+func worse(a, b Match) bool {
+	if a.Dist != b.Dist {
+		return a.Dist > b.Dist
+	}
+	if a.Offset != b.Offset {
+		return a.Offset > b.Offset
+	}
+	return a.Len < b.Len
+}
+
+func (b *bestMatches) Len() int           { return len(b.matches) }
+func (b *bestMatches) Less(i, j int) bool { return worse(b.matches[i], b.matches[j]) }
+func (b *bestMatches) Swap(i, j int)      { b.matches[i], b.matches[j] = b.matches[j], b.matches[i] }
+func (b *bestMatches) Push(x any)         { b.matches = append(b.matches, x.(Match)) }
+
+func (b *bestMatches) Pop() any {
+	last := b.matches[len(b.matches)-1]
+	b.matches = b.matches[:len(b.matches)-1]
+	return last
+}
+
+// add keeps match if it is among the n closest so far. It always returns true,
+// so that it can be used as the callback of a search.
+func (b *bestMatches) add(match Match) bool {
+	if len(b.matches) < b.n {
+		heap.Push(b, match)
+	} else if worse(b.matches[0], match) {
+		b.matches[0] = match
+		heap.Fix(b, 0)
+	}
+
+	return true
+}
+
+// emit calls fn for the kept matches, closest first.
+func (b *bestMatches) emit(fn func(Match) bool) {
+	sorted := make([]Match, len(b.matches))
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(b).(Match)
+	}
+
+	for _, match := range sorted {
+		if !fn(match) {
+			return
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package bench
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Bitap algorithm (Wu-Manber), for finding a word with up to k substitutions,
+// insertions or deletions. The state is one machine word per number of errors:
+// bit i of R[d] is set when word[:i+1] matches the end of the line read so far
+// with at most d errors, so words can be at most 64 bytes long.
+// via: http://en.wikipedia.org/wiki/Bitap_algorithm
+type bitap struct {
+	word []byte
+	k    int
+	mask [256]uint64 // bit i of mask[b] is set when word[i] == b
+}
+
+// bitapMaxLen is the longest word bitap can search for.
+const bitapMaxLen = 64
+
+// builds the Bitap masks for finding word with up to k errors
+func bitapBuild(word []byte, k int) *bitap {
+	bp := &bitap{word: word, k: k}
+	for i, b := range word {
+		bp.mask[b] |= 1 << uint(i)
+	}
+
+	return bp
+}
+
+// bitapSearch stores into buf.hits, for every position in line where the word
+// ends with at most k errors, the match ending there with the fewest errors,
+// then keeps only the closest of those that start at the same place. Bitap
+// only finds where matches end; approxStart works out where they start.
+func bitapSearch(bp *bitap, line []byte, buf *searchBuffer) []hit {
+	k := bp.k
+	found := uint64(1) << uint(len(bp.word)-1)
+
+	// "empty" the initial result by setting its length to zero
+	buf.hits = buf.hits[0:0]
+
+	// before reading anything, word[:d] matches with d deletions
+	R := append(buf.bits[0:0], make([]uint64, k+1)...)
+	for d := range R {
+		R[d] = 1<<uint(d) - 1
+	}
+	buf.bits = R
+
+	for j, b := range line {
+		mask := bp.mask[b]
+
+		prev := R[0] // R[d-1] before reading b
+		R[0] = (R[0]<<1 | 1) & mask
+		for d := 1; d <= k; d++ {
+			old := R[d]
+			R[d] = (old<<1|1)&mask | // b matches word[i]
+				prev<<1 | 1 | // b replaces word[i]
+				prev | // b is inserted after word[i]
+				R[d-1]<<1 | 1 // word[i] is deleted
+			prev = old
+		}
+
+		if R[k]&found != 0 {
+			start, dist := approxStart(bp.word, line[:j+1], len(bp.word)+k, buf)
+			buf.hits = append(buf.hits, hit{start: start, end: j + 1, dist: dist})
+		}
+	}
+
+	buf.hits = closestPerStart(buf.hits)
+	return buf.hits
+}
+
+// closestPerStart sorts hits by where they start and keeps one hit for each
+// start: the one with the fewest errors, and the shortest of those, so that
+// one occurrence is not also reported with an extra byte or one less.
+func closestPerStart(hits []hit) []hit {
+	slices.SortFunc(hits, func(a, b hit) int {
+		return cmp.Or(a.start-b.start, a.dist-b.dist, a.end-b.end)
+	})
+
+	return slices.CompactFunc(hits, func(a, b hit) bool { return a.start == b.start })
+}
+
+// approxStart returns where the closest match of word that ends at the end of
+// text starts, and its edit distance, looking back at most span bytes. Of
+// equally close matches, the longest is taken. It runs the usual dynamic
+// programming for edit distance over the reversed word and text, keeping one
+// row in buf.row.
+func approxStart(word, text []byte, span int, buf *searchBuffer) (start, dist int) {
+	n := min(span, len(text))
+
+	// row[j] is the distance between the last i bytes of word and the
+	// last j bytes of text
+	row := append(buf.row[0:0], make([]int, n+1)...)
+	for j := range row {
+		row[j] = j
+	}
+	buf.row = row
+
+	for i := 1; i <= len(word); i++ {
+		diag := row[0]
+		row[0] = i
+		for j := 1; j <= n; j++ {
+			cost := diag
+			if word[len(word)-i] != text[len(text)-j] {
+				cost++
+			}
+			diag = row[j]
+			row[j] = min(cost, diag+1, row[j-1]+1)
+		}
+	}
+
+	best := 0
+	for j := 1; j <= n; j++ {
+		if row[j] <= row[best] {
+			best = j
+		}
+	}
+
+	return len(text) - best, row[best]
+}
@@ -0,0 +1,191 @@
+package bench
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// editDistance is the textbook Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	D := make([][]int, len(a)+1)
+	for i := range D {
+		D[i] = make([]int, len(b)+1)
+		D[i][0] = i
+	}
+	for j := range D[0] {
+		D[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			D[i][j] = min(D[i-1][j-1]+cost, D[i-1][j]+1, D[i][j-1]+1)
+		}
+	}
+
+	return D[len(a)][len(b)]
+}
+
+// naiveApprox is the reference for approximate matching: for every end
+// position, the closest match ending there, preferring the longest, if it has
+// at most k errors; then of those that start at the same place, the closest,
+// preferring the shortest. The result is ordered like Matcher reports it.
+func naiveApprox(word, line string, k int) []hit {
+	byStart := map[int]hit{}
+	for end := 1; end <= len(line); end++ {
+		// a match any longer would need more than k insertions
+		best := hit{dist: k + 1}
+		for start := end - 1; start >= max(end-len(word)-k, 0); start-- {
+			if d := editDistance(word, line[start:end]); d <= best.dist {
+				best = hit{start: start, end: end, dist: d}
+			}
+		}
+		if h, ok := byStart[best.start]; best.dist <= k && (!ok || best.dist < h.dist) {
+			byStart[best.start] = best
+		}
+	}
+
+	var hits []hit
+	for _, h := range byStart {
+		hits = append(hits, h)
+	}
+	m := &Matcher{}
+	m.sortHits(hits)
+	return hits
+}
+
+func TestFindWithOptions_maxErrors(t *testing.T) {
+	opts := DefaultOptions()
+	opts.MaxErrors = 1
+
+	// line 1 has "aabbcc" itself, which "aabbc" and "aabbccd" one error away
+	// do not repeat; line 6 has "aabbbc", and "aabbbcc" is no closer
+	got, err := FindWithOptions(path, "aabbcc", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "1:0:6:0,1:10:6:0,6:1:6:1"
+	if got != expected {
+		t.Errorf("FindWithOptions(%q, %q) => %q, want %q", path, "aabbcc", got, expected)
+	}
+}
+
+func TestFindWithOptions_maxErrorsBad(t *testing.T) {
+	for _, tc := range []struct {
+		s         string
+		maxErrors int
+	}{
+		{"aa", -1},
+		{"aa", 2},
+	} {
+		opts := DefaultOptions()
+		opts.MaxErrors = tc.maxErrors
+		if _, err := FindWithOptions(path, tc.s, opts); err == nil {
+			t.Errorf("FindWithOptions(%q) with MaxErrors %d: some kind of error should be returned", tc.s, tc.maxErrors)
+		}
+	}
+
+	opts := DefaultOptions()
+	opts.MaxErrors = 1
+	if _, err := CompileAllWithOptions([]string{"aa", "bb"}, opts); err == nil {
+		t.Error("some kind of error should be returned")
+	}
+
+	opts.Algorithm = Bitap
+	if _, err := CompileWithOptions(strings.Repeat("a", bitapMaxLen+1), opts); err == nil {
+		t.Error("some kind of error should be returned")
+	}
+}
+
+func TestBitap_random(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for _, alphabet := range []string{"ab", "abc", "abcdefghij"} {
+		for n := 0; n < 200; n++ {
+			word := make([]byte, 2+rnd.Intn(6))
+			for i := range word {
+				word[i] = alphabet[rnd.Intn(len(alphabet))]
+			}
+			line := make([]byte, rnd.Intn(32))
+			for i := range line {
+				line[i] = alphabet[rnd.Intn(len(alphabet))]
+			}
+
+			opts := DefaultOptions()
+			opts.MaxErrors = 1 + rnd.Intn(len(word)-1)
+			m, err := CompileWithOptions(string(word), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := naiveApprox(string(word), string(line), opts.MaxErrors)
+			got := m.FindBytes(line)
+			if !equalApprox(got, expected) {
+				t.Fatalf("%q in %q with %d errors => %v, want %v", word, line, opts.MaxErrors, got, expected)
+			}
+		}
+	}
+}
+
+func TestBitap_longLine(t *testing.T) {
+	// matches on both sides of every window boundary, each one with an error
+	word := "abcdef"
+	line := []byte(strings.Repeat("x", 3*searchWindow))
+	for _, col := range []int{0, searchWindow - 3, 2*searchWindow - 1, 3*searchWindow - 5} {
+		copy(line[col:], "abXdef")
+	}
+
+	opts := DefaultOptions()
+	opts.MaxErrors = 1
+	m, err := CompileWithOptions(word, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := naiveApprox(word, string(line[:searchWindow+16]), 1)
+	for _, h := range naiveApprox(word, string(line[searchWindow+16:]), 1) {
+		h.start += searchWindow + 16
+		h.end += searchWindow + 16
+		expected = append(expected, h)
+	}
+
+	if got := m.FindBytes(line); !equalApprox(got, expected) {
+		t.Errorf("FindBytes => %v, want %v", got, expected)
+	}
+}
+
+func equalApprox(got []Match, expected []hit) bool {
+	if len(got) != len(expected) {
+		return false
+	}
+	for i, match := range got {
+		h := expected[i]
+		if match.Col != h.start || match.Len != h.end-h.start || match.Dist != h.dist {
+			return false
+		}
+	}
+
+	return true
+}
+
+func Test_approxStart(t *testing.T) {
+	for _, tc := range []struct {
+		word, text  string
+		start, dist int
+	}{
+		{"abc", "xxabc", 2, 0},
+		{"abc", "xxabxc", 2, 1},
+		{"abc", "xxac", 2, 1},
+		{"abc", "aabc", 1, 0},
+	} {
+		start, dist := approxStart([]byte(tc.word), []byte(tc.text), len(tc.word)+1, &searchBuffer{})
+		if start != tc.start || dist != tc.dist {
+			t.Errorf("approxStart(%q, %q) => %d, %d, want %d, %d", tc.word, tc.text, start, dist, tc.start, tc.dist)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package bench
+
+// Boyer-Moore algorithm with the bad-character and good-suffix rules, plus
+// Galil's rule, modified to return all occurrences, including overlapping ones,
+// in the same way as kmpSearch.
+//
+// After a match the word moves along by its period, so its first
+// len(word)-period bytes are already known to match; Galil's rule stops the
+// comparison there, which keeps the worst case linear even for periodic words
+// such as "aa" in data-large.txt.
+// via: http://en.wikipedia.org/wiki/Boyer–Moore_string_search_algorithm
+func bmSearch(skip *[256]int, gs []int, word, line []byte, result []int) []int {
+	last := len(word) - 1
+	period := gs[0]
+	memo := 0 // word[:memo] is known to match at the current position
+
+	// "empty" the initial result by setting its length to zero
+	result = result[0:0]
+
+	for m := 0; m+last < len(line); {
+		i := last
+		for i >= memo && word[i] == line[m+i] {
+			i--
+		}
+
+		if i < memo {
+			// got a match
+			result = append(result, m)
+			m += period
+			memo = len(word) - period
+			continue
+		}
+
+		// bad-character rule: line[m+i] lines up with its last occurrence in
+		// word; skip is relative to the last position, so correct for i
+		shift := skip[line[m+i]] - (last - i)
+		if gs[i] > shift {
+			shift = gs[i]
+		}
+
+		m += shift
+		memo = 0
+	}
+
+	return result
+}
+
+// builds the good-suffix table "gs" for Boyer-Moore string search: when
+// word[i] mismatches after word[i+1:] matched, gs[i] is the smallest shift
+// that lines up another copy of word[i+1:] (or a prefix of the word matching
+// its end) preceded by a different byte. gs[0] is the period of the word.
+// via: http://www-igm.univ-mlv.fr/~lecroq/string/node14.html
+func bmBuildGoodSuffixTable(word []byte) []int {
+	n := len(word)
+	gs := make([]int, n)
+	suff := bmSuffixes(word)
+
+	for i := range gs {
+		gs[i] = n
+	}
+
+	// shifts that line up a prefix of the word with the end of the match
+	j := 0
+	for i := n - 1; i >= 0; i-- {
+		if suff[i] == i+1 {
+			for ; j < n-1-i; j++ {
+				if gs[j] == n {
+					gs[j] = n - 1 - i
+				}
+			}
+		}
+	}
+
+	// shifts that line up another whole copy of the matched suffix
+	for i := 0; i <= n-2; i++ {
+		gs[n-1-suff[i]] = n - 1 - i
+	}
+
+	return gs
+}
+
+// bmSuffixes returns, for each i, the length of the longest substring of word
+// ending at i that is also a suffix of word.
+func bmSuffixes(word []byte) []int {
+	n := len(word)
+	suff := make([]int, n)
+	suff[n-1] = n
+
+	f := 0
+	g := n - 1
+	for i := n - 2; i >= 0; i-- {
+		if i > g && suff[i+n-1-f] < i-g {
+			suff[i] = suff[i+n-1-f]
+		} else {
+			if i < g {
+				g = i
+			}
+			f = i
+			for g >= 0 && word[g] == word[g+n-1-f] {
+				g--
+			}
+			suff[i] = f - g
+		}
+	}
+
+	return suff
+}
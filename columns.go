@@ -0,0 +1,124 @@
+package bench
+
+import "unicode/utf8"
+
+// ColumnUnit selects what Match.Col and Match.Len count.
+type ColumnUnit int
+
+const (
+	ByteColumns     ColumnUnit = iota // bytes, the default
+	RuneColumns                       // Unicode code points
+	UTF16Columns                      // UTF-16 code units, as used by LSP
+	GraphemeColumns                   // grapheme clusters, as defined by UAX #29
+)
+
+// columnCounter turns byte offsets into a line into columns in another unit.
+// Columns are counted by decoding the line with utf8.DecodeRune, so each byte
+// that is not part of valid UTF-8 counts as one column, as it would once
+// replaced by U+FFFD. A match that starts or ends inside a rune has the bytes
+// of that rune before it counted the same way, and its length is the column
+// it ends at less the one it starts at. In grapheme clusters, a match that
+// starts or ends inside a cluster is widened to the whole cluster instead.
+//
+// The line is decoded from the start of the previous match, so the matches of
+// a line are best counted in order.
+type columnCounter struct {
+	unit ColumnUnit
+	line []byte
+
+	// the first pos bytes of line hold n columns, and pos is the start of a rune
+	pos, n int
+}
+
+func (c *columnCounter) reset(line []byte) {
+	c.line = line
+	c.pos, c.n = 0, 0
+}
+
+// count returns the number of columns in line[:col], and moves on to col.
+func (c *columnCounter) count(col int) int {
+	if col < c.pos {
+		c.pos, c.n = 0, 0
+	}
+
+	var partial int
+	c.pos, c.n, partial = c.scan(c.pos, c.n, col)
+	if c.unit == GraphemeColumns {
+		return c.n
+	}
+
+	return c.n + partial
+}
+
+// end is count for the offset a match ends at: in grapheme clusters, a
+// cluster that col is inside of is counted whole.
+func (c *columnCounter) end(col int) int {
+	n := c.count(col)
+	if c.unit == GraphemeColumns && c.pos != col {
+		n++
+	}
+
+	return n
+}
+
+// length returns the number of columns in line[col:end], where col was the
+// last offset given to count.
+func (c *columnCounter) length(col, end int) int {
+	_, n, partial := c.scan(c.pos, c.n, end)
+	if c.unit == GraphemeColumns {
+		partial = min(partial, 1)
+	}
+
+	return n + partial - c.count(col)
+}
+
+// aligned reports whether line[col:end] starts and ends between columns,
+// where col is not before the last offset given to count.
+func (c *columnCounter) aligned(col, end int) bool {
+	c.count(col)
+	if c.pos != col {
+		return false
+	}
+
+	_, _, partial := c.scan(c.pos, c.n, end)
+	return partial == 0
+}
+
+// scan decodes whole runes of line from pos up to at most to, where line[:pos]
+// holds n columns, and returns where it stopped, the columns up to there, and
+// the bytes left before to, which are part of a rune that runs past it.
+func (c *columnCounter) scan(pos, n, to int) (int, int, int) {
+	if c.unit == GraphemeColumns {
+		for pos < to {
+			size := nextGrapheme(c.line[pos:])
+			if pos+size > to {
+				break
+			}
+			pos += size
+			n++
+		}
+		return pos, n, to - pos
+	}
+
+	for pos < to {
+		b := c.line[pos]
+		if b < utf8.RuneSelf {
+			pos++
+			n++
+			continue
+		}
+
+		r, size := utf8.DecodeRune(c.line[pos:])
+		if pos+size > to {
+			break
+		}
+		pos += size
+		n++
+		if c.unit == UTF16Columns && r >= 0x10000 {
+			// outside the BMP, a surrogate pair
+			n++
+		}
+	}
+
+	return pos, n, to - pos
+}
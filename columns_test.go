@@ -0,0 +1,110 @@
+package bench
+
+import (
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+func TestColumns(t *testing.T) {
+	const line = "héllo wörld 😀x"
+
+	for _, tc := range []struct {
+		unit     ColumnUnit
+		fold     Fold
+		s, line  string
+		col, len int
+	}{
+		{ByteColumns, NoFold, "😀x", line, 14, 5},
+		{RuneColumns, NoFold, "😀x", line, 12, 2},
+		{UTF16Columns, NoFold, "😀x", line, 12, 3},
+		{RuneColumns, NoFold, "wö", line, 6, 2},
+		{UTF16Columns, NoFold, "wö", line, 6, 2},
+
+		// each invalid byte is one column
+		{RuneColumns, NoFold, "b", "a\xff\xfeb", 3, 1},
+		{UTF16Columns, NoFold, "\xffb", "a\xffb", 1, 2},
+
+		// a match inside a rune counts the bytes before it one by one, and
+		// the rune it ends in, once whole, as one column
+		{RuneColumns, NoFold, "\xa9", "é", 1, 0},
+		{UTF16Columns, NoFold, "\x98\x80x", "😀x", 2, 1},
+
+		// columns point into the original line, not the folded one
+		{RuneColumns, FoldUnicode, "kelvin", "éKELVIN", 1, 6},
+		{UTF16Columns, FoldUnicode, "😀k", "😀K", 0, 3},
+	} {
+		opts := DefaultOptions()
+		opts.Columns = tc.unit
+		opts.Fold = tc.fold
+		m, err := CompileWithOptions(tc.s, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := m.FindBytes([]byte(tc.line))
+		if len(got) != 1 || got[0].Col != tc.col || got[0].Len != tc.len {
+			t.Errorf("unit %d: %q in %q => %+v, want col %d len %d", tc.unit, tc.s, tc.line, got, tc.col, tc.len)
+		}
+	}
+}
+
+func TestColumns_data(t *testing.T) {
+	for _, unit := range []ColumnUnit{RuneColumns, UTF16Columns} {
+		opts := DefaultOptions()
+		opts.Columns = unit
+
+		for _, tc := range []struct{ path, want string }{{path, want}, {pathLarge, wantLarge}} {
+			got, err := FindWithOptions(tc.path, word, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("unit %d: FindWithOptions(%q, %q) => %q, want %q", unit, tc.path, word, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestColumns_utf16(t *testing.T) {
+	line := "a😀b€c😀😀d\xffe"
+
+	// a match from every rune to the end of the line, each checked against
+	// utf16.Encode
+	opts := DefaultOptions()
+	opts.Columns = UTF16Columns
+	opts.Regexp = true
+	m, err := CompileWithOptions(".+", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches := map[int64]Match{}
+	for _, match := range m.FindBytes([]byte(line)) {
+		matches[match.Offset] = match
+	}
+
+	runes := []rune(line)
+	offset := 0
+	for i, r := range runes {
+		col, n := len(utf16.Encode(runes[:i])), len(utf16.Encode(runes[i:]))
+		if match := matches[int64(offset)]; match.Col != col || match.Len != n {
+			t.Errorf("rune %d => col %d len %d, want col %d len %d", i, match.Col, match.Len, col, n)
+		}
+		if r == utf8.RuneError {
+			offset++
+		} else {
+			offset += utf8.RuneLen(r)
+		}
+	}
+}
+
+func Test_columnCounter_backwards(t *testing.T) {
+	c := columnCounter{unit: RuneColumns}
+	c.reset([]byte("ééé"))
+	for _, tc := range []struct{ col, want int }{{4, 2}, {2, 1}, {6, 3}, {0, 0}, {5, 3}} {
+		if got := c.count(tc.col); got != tc.want {
+			t.Errorf("count(%d) => %d, want %d", tc.col, got, tc.want)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package bench
 
-import "testing"
+import (
+	"context"
+	"strings"
+	"testing"
+)
 
 // path is a text file path.
 const path = "./data.txt"
@@ -51,6 +55,128 @@ func TestFind_emptyResult(t *testing.T) {
 	}
 }
 
+func TestFindReader(t *testing.T) {
+	got, err := FindReader(strings.NewReader("xaax\n\naaa"), word)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "1:1,3:0,3:1"
+	if got != expected {
+		t.Errorf("FindReader(%q) => %q, want %q", word, got, expected)
+	}
+}
+
+func TestFindReader_overlapping(t *testing.T) {
+	for _, tc := range []struct{ s, want string }{{"a", "1:0,1:1,1:2,1:3"}, {"aaa", "1:0,1:1"}} {
+		got, err := FindReader(strings.NewReader("aaaa"), tc.s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("FindReader(%q) => %q, want %q", tc.s, got, tc.want)
+		}
+	}
+}
+
+func TestFindReader_emptyWord(t *testing.T) {
+	if _, err := FindReader(strings.NewReader("aa"), ""); err == nil {
+		t.Error("some kind of error should be returned")
+	}
+}
+
+func TestFindWithOptions(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts func(*Options)
+		want string
+	}{
+		{"default", func(o *Options) {}, want},
+		{"no overlap", func(o *Options) { o.Overlap = false }, "1:0,1:10,6:0"},
+		{"bases", func(o *Options) { o.RowBase, o.ColBase = 0, 1 }, "0:1,0:11,5:1,5:2"},
+		{"sep", func(o *Options) { o.Sep = " " }, "1:0 1:10 6:0 6:1"},
+		{"max", func(o *Options) { o.MaxMatches = 2 }, "1:0,1:10"},
+	} {
+		opts := DefaultOptions()
+		tc.opts(&opts)
+
+		got, err := FindWithOptions(path, word, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: FindWithOptions(%q, %q) => %q, want %q", tc.name, path, word, got, tc.want)
+		}
+	}
+}
+
+func TestFindWithOptions_badMax(t *testing.T) {
+	opts := DefaultOptions()
+	opts.MaxMatches = -1
+	if _, err := FindWithOptions(path, word, opts); err == nil {
+		t.Error("some kind of error should be returned")
+	}
+}
+
+func TestFindWithOptions_zero(t *testing.T) {
+	if _, err := FindWithOptions(path, word, Options{}); err == nil {
+		t.Error("some kind of error should be returned")
+	}
+}
+
+func TestFindContext(t *testing.T) {
+	got, err := FindContext(context.Background(), path, word)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("FindContext(%q, %q) => %q, want %q", path, word, got, want)
+	}
+}
+
+func TestFindContext_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	got, err := FindContext(ctx, path, word)
+	if err != context.Canceled {
+		t.Errorf("FindContext returned error %v, want %v", err, context.Canceled)
+	}
+	if got != "" {
+		t.Errorf("FindContext(%q, %q) => %q, want nothing", path, word, got)
+	}
+}
+
+func TestFindMatches(t *testing.T) {
+	got, err := FindMatches(path, word)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Match{
+		{Row: 1, Col: 0, Offset: 0, Len: 2, EndRow: 1, EndCol: 2},
+		{Row: 1, Col: 10, Offset: 10, Len: 2, EndRow: 1, EndCol: 12},
+		{Row: 6, Col: 0, Offset: 105, Len: 2, EndRow: 6, EndCol: 2},
+		{Row: 6, Col: 1, Offset: 106, Len: 2, EndRow: 6, EndCol: 3},
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("FindMatches(%q, %q) => %v, want %v", path, word, got, expected)
+	}
+
+	for i, m := range got {
+		if m != expected[i] {
+			t.Errorf("FindMatches(%q, %q)[%d] => %+v, want %+v", path, word, i, m, expected[i])
+		}
+	}
+}
+
+func TestFindMatches_emptyWord(t *testing.T) {
+	if _, err := FindMatches(path, ""); err == nil {
+		t.Error("some kind of error should be returned")
+	}
+}
+
 func Test_kmpBuildTable_ABCDABD(t *testing.T) {
 	W := "ABCDABD"
 	T := kmpBuildTable(W)
@@ -58,7 +184,7 @@ func Test_kmpBuildTable_ABCDABD(t *testing.T) {
 	expected := []int{-1, 0, 0, 0, 0, 1, 2}
 
 	if len(expected) != len(T) {
-		t.Errorf("kmpBuildTable(%q) => %q, want %q", W, T, expected)
+		t.Errorf("kmpBuildTable(%q) => %v, want %v", W, T, expected)
 	}
 
 	for i, v := range T {
@@ -76,7 +202,7 @@ func Test_kmpBuildTable_PARTICIPATE(t *testing.T) {
 	expected := []int{-1, 0, 0, 0, 0, 0, 0, 0, 1, 2, 0, 0, 0, 0, 0, 0, 1, 2, 3, 0, 0, 0, 0, 0}
 
 	if len(expected) != len(T) {
-		t.Errorf("kmpBuildTable(%q) => %q, want %q", W, T, expected)
+		t.Errorf("kmpBuildTable(%q) => %v, want %v", W, T, expected)
 	}
 
 	for i, v := range T {
@@ -88,7 +214,46 @@ func Test_kmpBuildTable_PARTICIPATE(t *testing.T) {
 }
 
 func BenchmarkFind(b *testing.B) {
-	for i := 0; i < b.N; i++ {
-		Find(pathLarge, word)
+	for _, algo := range []Algorithm{KMP, Horspool, BoyerMoore, TwoWay, AhoCorasick, RabinKarp, Auto} {
+		opts := DefaultOptions()
+		opts.Algorithm = algo
+
+		b.Run(algo.String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				FindWithOptions(pathLarge, word, opts)
+			}
+		})
+
+		// a long word with few repeated bytes, where skipping pays off
+		b.Run(algo.String()+"/long", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				FindWithOptions(path, "ssstttuuuvvvwwwxxx", opts)
+			}
+		})
+	}
+}
+
+func Test_kmpSearchNonOverlapping(t *testing.T) {
+	for _, tc := range []struct {
+		word, line string
+		want       []int
+	}{
+		{"aa", "aaaa", []int{0, 2}},
+		{"aa", "aaaaa", []int{0, 2}},
+		{"aba", "ababababa", []int{0, 4}},
+		{"abab", "abaababab", []int{3}},
+		{"ab", "", nil},
+	} {
+		T := kmpBuildTable(tc.word)
+		got := kmpSearchNonOverlapping(T, []byte(tc.word), []byte(tc.line), nil)
+		if !equalInts(got, tc.want) {
+			t.Errorf("kmpSearchNonOverlapping(%q, %q) => %v, want %v", tc.word, tc.line, got, tc.want)
+		}
+	}
+
+	// with overlap, the same line gives every start
+	T := kmpBuildTable("aa")
+	if got := kmpSearch(T, []byte("aa"), []byte("aaaa"), nil); !equalInts(got, []int{0, 1, 2}) {
+		t.Errorf("kmpSearch(%q, %q) => %v, want %v", "aa", "aaaa", got, []int{0, 1, 2})
 	}
 }
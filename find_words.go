@@ -8,19 +8,80 @@ package bench
 // George Armhold, March 2015
 
 import (
-	"bufio"
-	"errors"
-	"fmt"
+	"bytes"
+	"context"
+	"io"
 	"os"
+	"strconv"
 )
 
+// Match is a single occurrence of the search word.
+type Match struct {
+	Row     int   // line number, starting at 1
+	Col     int   // offset of the match within its line, in bytes unless Options.Columns says otherwise
+	Offset  int64 // byte offset of the match from the start of the file
+	Len     int   // length of the match, in the same unit as Col
+	Pattern int   // index of the pattern found, when searching for several
+	Dist    int   // edit distance from the pattern, when matching with errors
+	EndRow  int   // line number the match ends on, which is Row unless Options.Multiline is set
+	EndCol  int   // column just past the end of the match within line EndRow
+}
+
+// String formats the match the way Find reports it, e.g. "6:1".
+func (m Match) String() string {
+	return strconv.Itoa(m.Row) + ":" + strconv.Itoa(m.Col)
+}
+
+// Find returns every occurrence of s in the file at path, formatted as a
+// comma-separated list of row:col pairs, e.g. "1:0,1:10,6:0".
 func Find(path, s string) (string, error) {
-	if s == "" {
-		return "", errors.New("s cannot be empty")
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
 	}
+	defer file.Close()
+
+	return FindReader(file, s)
+}
 
-	T := kmpBuildTable(s)
-	sBytes := []byte(s)
+// FindReader is like Find, but searches r instead of a named file.
+func FindReader(r io.Reader, s string) (string, error) {
+	m, err := Compile(s)
+	if err != nil {
+		return "", err
+	}
+
+	matches, err := m.FindReader(r)
+	if err != nil {
+		return "", err
+	}
+
+	return m.Format(matches), nil
+}
+
+// FindWithOptions is like Find, but searches and formats the results
+// according to opts.
+func FindWithOptions(path, s string, opts Options) (string, error) {
+	m, err := CompileWithOptions(s, opts)
+	if err != nil {
+		return "", err
+	}
+
+	matches, err := m.FindFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return m.Format(matches), nil
+}
+
+// FindContext is like Find, but gives up once ctx is done. The matches found
+// up to that point are returned along with ctx.Err().
+func FindContext(ctx context.Context, path, s string) (string, error) {
+	m, err := Compile(s)
+	if err != nil {
+		return "", err
+	}
 
 	file, err := os.Open(path)
 	if err != nil {
@@ -28,26 +89,75 @@ func Find(path, s string) (string, error) {
 	}
 	defer file.Close()
 
-//	searchResultBuffer := make([]int, 0, 1000)
-	var searchResultBuffer []int
-	result := ""
-	sep := ""
-	row := 1
+	matches, err := m.FindReaderContext(ctx, file)
+	return m.Format(matches), err
+}
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		searchResultBuffer = kmpSearch(T, sBytes, line, searchResultBuffer)
+// FindAll returns every occurrence of any of patterns in the file at path,
+// reading it only once. Match.Pattern tells which of them was found.
+func FindAll(path string, patterns []string) ([]Match, error) {
+	m, err := CompileAll(patterns)
+	if err != nil {
+		return nil, err
+	}
 
-		for _, col := range searchResultBuffer {
-			result = result + fmt.Sprintf("%s%d:%d", sep, row, col)
-			sep = ","
-		}
+	return m.FindFile(path)
+}
 
-		row++
+// FindMatches returns every occurrence of s in the file at path.
+func FindMatches(path, s string) ([]Match, error) {
+	m, err := Compile(s)
+	if err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return m.FindFile(path)
+}
+
+// formatFields says which Match fields follow row:col in formatted results.
+type formatFields int
+
+const (
+	fmtLen     formatFields = 1 << iota // length, when matches can differ in length
+	fmtDist                             // edit distance, when matching with errors
+	fmtEnd                              // -endrow:endcol, when matches can span lines, instead of the length
+	fmtPattern                          // index of the pattern found, when searching for several
+
+	fmtRowCol formatFields = 0
+)
+
+// formatMatches joins matches as row:col pairs separated by sep, extended by
+// fields with :len or -endrow:endcol, then :dist, then :pattern, e.g.
+// row:col:len:dist or row:col:len:pattern.
+func formatMatches(matches []Match, sep string, fields formatFields) string {
+	var buf bytes.Buffer
+	for i, m := range matches {
+		if i > 0 {
+			buf.WriteString(sep)
+		}
+		buf.WriteString(strconv.Itoa(m.Row))
+		buf.WriteByte(':')
+		buf.WriteString(strconv.Itoa(m.Col))
+		if fields&fmtEnd != 0 {
+			buf.WriteByte('-')
+			buf.WriteString(strconv.Itoa(m.EndRow))
+			buf.WriteByte(':')
+			buf.WriteString(strconv.Itoa(m.EndCol))
+		} else if fields&fmtLen != 0 {
+			buf.WriteByte(':')
+			buf.WriteString(strconv.Itoa(m.Len))
+		}
+		if fields&fmtDist != 0 {
+			buf.WriteByte(':')
+			buf.WriteString(strconv.Itoa(m.Dist))
+		}
+		if fields&fmtPattern != 0 {
+			buf.WriteByte(':')
+			buf.WriteString(strconv.Itoa(m.Pattern))
+		}
+	}
+
+	return buf.String()
 }
 
 // Knuth-Morris-Pratt algorithm, modified slightly to return all occurrences
@@ -63,24 +173,23 @@ func kmpSearch(T []int, word, line []byte, result []int) []int {
 
 	for m+i < len(line) {
 		if word[i] == line[m+i] {
-			if i == len(word)-1 {
-				// got a match
-				result = append(result, m)
-				matchCount++
-				m = m + i
-				i = 0
-			} else {
+			if i < len(word)-1 {
 				i++
+				continue
 			}
 
+			// got a match; carry on as if the last byte had mismatched, so
+			// that the shift never passes an overlapping occurrence
+			result = append(result, m)
+			matchCount++
+		}
+
+		if T[i] > -1 {
+			m = m + i - T[i]
+			i = T[i]
 		} else {
-			if T[i] > -1 {
-				m = m + i - T[i]
-				i = T[i]
-			} else {
-				i = 0
-				m++
-			}
+			i = 0
+			m++
 		}
 	}
 
@@ -88,6 +197,40 @@ func kmpSearch(T []int, word, line []byte, result []int) []int {
 	return result
 }
 
+// kmpSearchNonOverlapping is kmpSearch returning leftmost non-overlapping
+// occurrences only: after a match the search starts over at its end, so
+// "aa" in "aaaa" is found at 0 and 2.
+func kmpSearchNonOverlapping(T []int, word, line []byte, result []int) []int {
+	m := 0
+	i := 0
+
+	result = result[0:0]
+
+	for m+i < len(line) {
+		if word[i] == line[m+i] {
+			if i < len(word)-1 {
+				i++
+				continue
+			}
+
+			result = append(result, m)
+			m += len(word)
+			i = 0
+			continue
+		}
+
+		if T[i] > -1 {
+			m = m + i - T[i]
+			i = T[i]
+		} else {
+			i = 0
+			m++
+		}
+	}
+
+	return result
+}
+
 // builds the table "T" for Knuth-Morris-Pratt string search
 // via: http://en.wikipedia.org/wiki/Knuth–Morris–Pratt_algorithm
 func kmpBuildTable(word string) []int {
@@ -97,7 +240,9 @@ func kmpBuildTable(word string) []int {
 	cnd := 0
 
 	T[0] = -1
-	T[1] = 0
+	if len(word) > 1 {
+		T[1] = 0
+	}
 
 	for pos < len(word) {
 		if word[pos-1] == word[cnd] {
@@ -0,0 +1,133 @@
+package bench
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Case folding, for Options.Fold. Patterns are folded once when they are
+// compiled, and each line is folded as it is searched, so that the backends
+// still compare bytes exactly:
+//
+//   - FoldASCII maps A-Z to a-z, which never changes the length of a line.
+//     KMP does that inside its comparison loop; the other backends search a
+//     folded copy of the line.
+//   - FoldUnicode maps every rune to one representative of the runes it is
+//     equal to under simple case folding, which may be shorter or longer in
+//     UTF-8 (K, the Kelvin sign, becomes k). The folded copy of the line
+//     comes with the offset in the original line of each of its bytes, so
+//     that matches are reported in terms of the original.
+
+// asciiLower maps A-Z to a-z and every other byte to itself.
+var asciiLower = func() (t [256]byte) {
+	for b := range t {
+		t[b] = byte(b)
+	}
+	for b := 'A'; b <= 'Z'; b++ {
+		t[b] = byte(b - 'A' + 'a')
+	}
+	return t
+}()
+
+// foldRune returns the representative of the runes equal to r under simple
+// case folding: the lower case ASCII letter when there is one, otherwise the
+// smallest of them.
+func foldRune(r rune) rune {
+	if r < utf8.RuneSelf {
+		return rune(asciiLower[r])
+	}
+
+	least := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		least = min(least, f)
+	}
+	if least < utf8.RuneSelf {
+		return rune(asciiLower[least])
+	}
+
+	return least
+}
+
+// foldString returns s folded by mode.
+func foldString(s string, mode Fold) string {
+	var folded []byte
+	switch mode {
+	case FoldASCII:
+		folded = foldASCII(nil, []byte(s))
+	case FoldUnicode:
+		folded, _ = foldUnicode(nil, nil, []byte(s))
+	default:
+		return s
+	}
+
+	return string(folded)
+}
+
+// foldASCII appends b to dst with A-Z mapped to a-z.
+func foldASCII(dst, b []byte) []byte {
+	for _, c := range b {
+		dst = append(dst, asciiLower[c])
+	}
+
+	return dst
+}
+
+// foldUnicode appends b to dst with every rune folded, and appends to offs
+// the offset in b of the rune each byte appended to dst came from, followed
+// by len(b). Bytes that are not valid UTF-8 are copied as they are.
+func foldUnicode(dst []byte, offs []int, b []byte) ([]byte, []int) {
+	for i := 0; i < len(b); {
+		if c := b[i]; c < utf8.RuneSelf {
+			dst = append(dst, asciiLower[c])
+			offs = append(offs, i)
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRune(b[i:])
+		n := len(dst)
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, b[i])
+		} else {
+			dst = utf8.AppendRune(dst, foldRune(r))
+		}
+		for range len(dst) - n {
+			offs = append(offs, i)
+		}
+		i += size
+	}
+
+	return dst, append(offs, len(b))
+}
+
+// kmpSearch with A-Z and a-z treated as equal: word must already be folded,
+// and each byte of line is folded as it is compared.
+func kmpSearchFold(T []int, word, line []byte, result []int) []int {
+	m := 0
+	i := 0
+
+	// "empty" the initial result by setting its length to zero
+	result = result[0:0]
+
+	for m+i < len(line) {
+		if word[i] == asciiLower[line[m+i]] {
+			if i < len(word)-1 {
+				i++
+				continue
+			}
+
+			// got a match; carry on as if the last byte had mismatched
+			result = append(result, m)
+		}
+
+		if T[i] > -1 {
+			m = m + i - T[i]
+			i = T[i]
+		} else {
+			i = 0
+			m++
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,178 @@
+package bench
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestFindWithOptions_fold(t *testing.T) {
+	for _, fold := range []Fold{FoldASCII, FoldUnicode} {
+		for _, algo := range algorithms {
+			opts := DefaultOptions()
+			opts.Algorithm = algo
+			opts.Fold = fold
+
+			got, err := FindWithOptions(path, "aA", opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("%v, fold %d: FindWithOptions(%q, %q) => %q, want %q", algo, fold, path, "aA", got, want)
+			}
+		}
+	}
+}
+
+func TestFold(t *testing.T) {
+	for _, tc := range []struct {
+		fold    Fold
+		s, line string
+		want    []Match
+	}{
+		{FoldASCII, "error", "Error ERROR eRRoR", []Match{{Row: 1, Col: 0, Len: 5, EndRow: 1, EndCol: 5}, {Row: 1, Col: 6, Offset: 6, Len: 5, EndRow: 1, EndCol: 11}, {Row: 1, Col: 12, Offset: 12, Len: 5, EndRow: 1, EndCol: 17}}},
+		{FoldASCII, "é", "É", nil},
+		{FoldASCII, "k", "K", nil},
+		{FoldUnicode, "é", "É", []Match{{Row: 1, Col: 0, Len: 2, EndRow: 1, EndCol: 2}}},
+
+		// the Kelvin sign is three bytes, k only one
+		{FoldUnicode, "kelvin", "KELVIN kelvin", []Match{{Row: 1, Col: 0, Len: 8, EndRow: 1, EndCol: 8}, {Row: 1, Col: 9, Offset: 9, Len: 6, EndRow: 1, EndCol: 15}}},
+		{FoldUnicode, "K", "xk", []Match{{Row: 1, Col: 1, Offset: 1, Len: 1, EndRow: 1, EndCol: 2}}},
+
+		// ẞ is three bytes, ß two
+		{FoldUnicode, "straße", "STRAẞE straße", []Match{{Row: 1, Col: 0, Len: 8, EndRow: 1, EndCol: 8}, {Row: 1, Col: 9, Offset: 9, Len: 7, EndRow: 1, EndCol: 16}}},
+		{FoldUnicode, "ΣΑΣ", "xσας\nΣΑΣ", []Match{{Row: 1, Col: 1, Offset: 1, Len: 6, EndRow: 1, EndCol: 7}, {Row: 2, Col: 0, Offset: 8, Len: 6, EndRow: 2, EndCol: 6}}},
+
+		// bytes that are not UTF-8 are compared as they are
+		{FoldUnicode, "\xffk", "\xffK\xfeK", []Match{{Row: 1, Col: 0, Len: 2, EndRow: 1, EndCol: 2}}},
+	} {
+		opts := DefaultOptions()
+		opts.Fold = tc.fold
+		for _, algo := range algorithms {
+			opts.Algorithm = algo
+			m, err := CompileWithOptions(tc.s, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := m.FindBytes([]byte(tc.line))
+			if len(got) != len(tc.want) {
+				t.Errorf("%v, fold %d: %q in %q => %v, want %v", algo, tc.fold, tc.s, tc.line, got, tc.want)
+				continue
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("%v, fold %d: %q in %q => %+v, want %+v", algo, tc.fold, tc.s, tc.line, got[i], tc.want[i])
+				}
+			}
+		}
+	}
+}
+
+func TestFold_random(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	// every rune here has another case, some of them of a different length
+	alphabet := []string{"a", "A", "k", "K", "K", "s", "S", "ſ", "ß", "ẞ", "σ", "ς", "Σ", " "}
+	random := func(n int) string {
+		var b strings.Builder
+		for range n {
+			b.WriteString(alphabet[rnd.Intn(len(alphabet))])
+		}
+		return b.String()
+	}
+
+	for n := 0; n < 300; n++ {
+		word, line := random(1+rnd.Intn(4)), random(rnd.Intn(40))
+
+		// the reference: runes are equal when they are in the same orbit
+		var expected []Match
+		runes := []rune(line)
+		for i := range runes {
+			j := 0
+			for _, r := range word {
+				if i+j >= len(runes) || foldRune(runes[i+j]) != foldRune(r) {
+					j = -1
+					break
+				}
+				j++
+			}
+			if j > 0 {
+				col, n := len(string(runes[:i])), len(string(runes[i:i+j]))
+				expected = append(expected, Match{Row: 1, Col: col, Offset: int64(col), Len: n, EndRow: 1, EndCol: col + n})
+			}
+		}
+
+		opts := DefaultOptions()
+		opts.Fold = FoldUnicode
+		for _, algo := range algorithms {
+			opts.Algorithm = algo
+			m, err := CompileWithOptions(word, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := m.FindBytes([]byte(line))
+			if len(got) != len(expected) {
+				t.Fatalf("%v: %q in %q => %v, want %v", algo, word, line, got, expected)
+			}
+			for i := range got {
+				if got[i] != expected[i] {
+					t.Fatalf("%v: %q in %q => %+v, want %+v", algo, word, line, got[i], expected[i])
+				}
+			}
+		}
+	}
+}
+
+func TestFold_globAndRegexp(t *testing.T) {
+	for _, tc := range []struct {
+		glob, regexp bool
+		fold         Fold
+		s, want      string
+	}{
+		{true, false, FoldASCII, "ERR?? code [0-9]", "1:0:12"},
+		{true, false, FoldUnicode, "[A-Z]rr", "1:0:3,1:15:3"},
+		{false, true, FoldASCII, `ERR\d+`, "1:0:5,1:15:4"},
+		{false, true, FoldUnicode, "é+", "1:20:4"},
+	} {
+		opts := DefaultOptions()
+		opts.Glob, opts.Regexp, opts.Fold = tc.glob, tc.regexp, tc.fold
+		opts.Overlap = false
+		m, err := CompileWithOptions(tc.s, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Format(m.FindBytes([]byte("err42 code 7   ERR9 éÉ"))); got != tc.want {
+			t.Errorf("%q => %q, want %q", tc.s, got, tc.want)
+		}
+	}
+}
+
+func Test_foldRune(t *testing.T) {
+	for _, tc := range []struct{ r, want rune }{
+		{'a', 'a'},
+		{'A', 'a'},
+		{'K', 'k'},
+		{'ſ', 's'},
+		{'ẞ', 'ß'},
+		{'ς', 'Σ'},
+		{'σ', 'Σ'},
+		{'1', '1'},
+		{'世', '世'},
+	} {
+		if got := foldRune(tc.r); got != tc.want {
+			t.Errorf("foldRune(%q) => %q, want %q", tc.r, got, tc.want)
+		}
+	}
+}
+
+func Test_foldUnicode_offsets(t *testing.T) {
+	folded, offs := foldUnicode(nil, nil, []byte("Ké\xff"))
+	if string(folded) != "kÉ\xff" {
+		t.Errorf("folded %q, want %q", folded, "kÉ\xff")
+	}
+	if expected := []int{0, 3, 3, 5, 6}; !equalInts(offs, expected) {
+		t.Errorf("offsets %v, want %v", offs, expected)
+	}
+}
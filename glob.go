@@ -0,0 +1,345 @@
+package bench
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Glob patterns, for Options.Glob:
+//
+//	?         any byte
+//	*         any run of bytes, including none
+//	[abc]     any of the bytes listed; ranges like [0-9] are allowed, and
+//	          [!abc] or [^abc] is any byte not listed
+//	x{n}      exactly n of the item x, where x is a byte, ? or a [class]
+//	x{n,m}    between n and m of the item x
+//	x{n,}     at least n of the item x
+//	\x        the byte x itself, e.g. \* or \?
+//
+// Classes and ? make "matches" a relation that is not transitive, which is
+// what the KMP table relies on, so globs run on a small NFA instead. Globs
+// with no wildcards at all are plain words and use the usual backends, and
+// the longest plain run of bytes in a glob is found with kmpSearch first to
+// rule out windows that cannot match.
+
+// byteSet is a set of bytes, one bit each.
+type byteSet [4]uint64
+
+func (s *byteSet) add(b byte)      { s[b>>6] |= 1 << (b & 63) }
+func (s *byteSet) has(b byte) bool { return s[b>>6]&(1<<(b&63)) != 0 }
+func (s *byteSet) invert()         { s[0], s[1], s[2], s[3] = ^s[0], ^s[1], ^s[2], ^s[3] }
+func (s *byteSet) addRange(lo, hi byte) {
+	for b := int(lo); b <= int(hi); b++ {
+		s.add(byte(b))
+	}
+}
+
+// single returns the byte in s, if s has exactly one.
+func (s *byteSet) single() (byte, bool) {
+	n := 0
+	var found byte
+	for b := 0; b < 256; b++ {
+		if s.has(byte(b)) {
+			n++
+			found = byte(b)
+		}
+	}
+
+	return found, n == 1
+}
+
+var anyByte = byteSet{^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0)}
+
+// a globItem matches one byte in set, or with optional also none, or with star
+// any number of them.
+type globItem struct {
+	set      byteSet
+	optional bool
+	star     bool
+}
+
+// maxGlobRepeat bounds x{n,m}, since each repetition is a state of the NFA.
+const maxGlobRepeat = 1024
+
+// parseGlob turns pattern into the sequence of items it matches.
+func parseGlob(pattern string) ([]globItem, error) {
+	var items []globItem
+
+	for i := 0; i < len(pattern); {
+		var item globItem
+
+		switch c := pattern[i]; c {
+		case '*':
+			items = append(items, globItem{set: anyByte, star: true})
+			i++
+			continue
+		case '?':
+			item.set = anyByte
+			i++
+		case '[':
+			n, err := parseGlobClass(pattern[i:], &item.set)
+			if err != nil {
+				return nil, err
+			}
+			i += n
+		case '\\':
+			if i+1 == len(pattern) {
+				return nil, errors.New("glob ends with a lone \\")
+			}
+			item.set.add(pattern[i+1])
+			i += 2
+		default:
+			item.set.add(c)
+			i++
+		}
+
+		lo, hi, n := parseGlobRepeat(pattern[i:])
+		if lo > maxGlobRepeat || hi > maxGlobRepeat {
+			return nil, fmt.Errorf("glob repeat %s is over %d", pattern[i:i+n], maxGlobRepeat)
+		}
+		if hi >= 0 && hi < lo {
+			return nil, fmt.Errorf("glob repeat %s has its bounds the wrong way round", pattern[i:i+n])
+		}
+		i += n
+
+		for j := 0; j < lo; j++ {
+			items = append(items, item)
+		}
+		switch {
+		case hi < 0:
+			items = append(items, globItem{set: item.set, star: true})
+		default:
+			for j := lo; j < hi; j++ {
+				items = append(items, globItem{set: item.set, optional: true})
+			}
+		}
+	}
+
+	if len(items) == 0 {
+		return nil, errors.New("s cannot be empty")
+	}
+
+	return items, nil
+}
+
+// parseGlobClass adds the bytes of the [class] at the start of s to set, and
+// returns the length of the class.
+func parseGlobClass(s string, set *byteSet) (int, error) {
+	i := 1
+	negate := i < len(s) && (s[i] == '!' || s[i] == '^')
+	if negate {
+		i++
+	}
+
+	// a ] straight after the [ is a member, not the end
+	for first := true; ; first = false {
+		if i == len(s) {
+			return 0, fmt.Errorf("glob class %s has no closing ]", s)
+		}
+		if s[i] == ']' && !first {
+			break
+		}
+
+		lo := s[i]
+		if lo == '\\' && i+1 < len(s) {
+			i++
+			lo = s[i]
+		}
+		i++
+
+		hi := lo
+		if i+1 < len(s) && s[i] == '-' && s[i+1] != ']' {
+			hi = s[i+1]
+			if hi == '\\' && i+2 < len(s) {
+				i++
+				hi = s[i+1]
+			}
+			i += 2
+			if hi < lo {
+				return 0, fmt.Errorf("glob class range %c-%c has its ends the wrong way round", lo, hi)
+			}
+		}
+		set.addRange(lo, hi)
+	}
+
+	if negate {
+		set.invert()
+	}
+
+	return i + 1, nil
+}
+
+// parseGlobRepeat parses a {n}, {n,m} or {n,} at the start of s, returning the
+// bounds, with hi -1 for no upper bound, and its length. Anything else
+// is not a repeat, and is one of the item.
+func parseGlobRepeat(s string) (lo, hi, n int) {
+	end := strings.IndexByte(s, '}')
+	if len(s) == 0 || s[0] != '{' || end < 0 {
+		return 1, 1, 0
+	}
+
+	body := s[1:end]
+	from, to, comma := strings.Cut(body, ",")
+	lo, err := strconv.Atoi(from)
+	if err != nil || lo < 0 {
+		return 1, 1, 0
+	}
+
+	switch {
+	case !comma:
+		hi = lo
+	case to == "":
+		hi = -1
+	default:
+		if hi, err = strconv.Atoi(to); err != nil || hi < 0 {
+			return 1, 1, 0
+		}
+	}
+
+	return lo, hi, end + 1
+}
+
+// globLiteral returns the bytes a glob matches when it has no wildcards.
+func globLiteral(items []globItem) ([]byte, bool) {
+	word := make([]byte, 0, len(items))
+	for _, item := range items {
+		b, ok := item.set.single()
+		if !ok || item.optional || item.star {
+			return nil, false
+		}
+		word = append(word, b)
+	}
+
+	return word, true
+}
+
+// globRequired returns the longest run of bytes every match of the glob must
+// contain.
+func globRequired(items []globItem) []byte {
+	var best, run []byte
+	for _, item := range items {
+		b, ok := item.set.single()
+		if !ok || item.optional || item.star {
+			run = run[0:0]
+			continue
+		}
+		run = append(run, b)
+		if len(run) > len(best) {
+			best = append(best[0:0], run...)
+		}
+	}
+
+	return best
+}
+
+// globSpan returns the length of the longest match of the glob, or -1 when a
+// * leaves it unbounded.
+func globSpan(items []globItem) int {
+	for _, item := range items {
+		if item.star {
+			return -1
+		}
+	}
+
+	return len(items)
+}
+
+// glob is a compiled glob pattern: the NFA that has a state for each item,
+// run from the end of the line back, plus the plain bytes every match must
+// contain.
+type glob struct {
+	items []globItem // in reverse order
+	lit   []byte
+	T     []int
+}
+
+func globBuild(items []globItem) *glob {
+	g := &glob{items: slices.Clone(items), lit: globRequired(items)}
+	slices.Reverse(g.items)
+	if len(g.lit) > 0 {
+		g.T = kmpBuildTable(string(g.lit))
+	}
+
+	return g
+}
+
+// globSearch stores into buf.hits, for every position in line where a match
+// of the glob starts, the shortest match starting there.
+//
+// It simulates the NFA backwards, from the end of line, with the items in
+// reverse order, so that state i means the last i items have matched. For
+// each state it keeps the earliest position at which a match could have ended
+// to reach it. That is the shortest match, and it takes O(len(items)) per
+// byte of line, however many matches overlap.
+func globSearch(g *glob, line []byte, buf *searchBuffer) []hit {
+	n := len(g.items)
+
+	// "empty" the initial result by setting its length to zero
+	buf.hits = buf.hits[0:0]
+
+	if len(g.lit) > 0 {
+		buf.cols = kmpSearch(g.T, g.lit, line, buf.cols)
+		if len(buf.cols) == 0 {
+			return buf.hits
+		}
+	}
+
+	// ends[i] is where the match in state i ends, or -1 if none does; next
+	// is the same after reading a byte
+	ends := append(buf.row[0:0], make([]int, 2*(n+1))...)
+	buf.row = ends
+	ends, next := ends[:n+1], ends[n+1:]
+	for i := range ends {
+		ends[i] = -1
+	}
+
+	for p := len(line) - 1; p >= 0; p-- {
+		// a new match can end here; it is the earliest so it wins
+		ends[0] = p + 1
+		g.skip(ends)
+
+		for i := range next {
+			next[i] = -1
+		}
+		for i, item := range g.items {
+			if ends[i] < 0 || !item.set.has(line[p]) {
+				continue
+			}
+			to := i + 1
+			if item.star {
+				to = i
+			}
+			next[to] = earlier(next[to], ends[i])
+		}
+		g.skip(next)
+		ends, next = next, ends
+
+		if ends[n] >= 0 {
+			buf.hits = append(buf.hits, hit{start: p, end: ends[n]})
+		}
+	}
+
+	slices.Reverse(buf.hits)
+	return buf.hits
+}
+
+// skip moves matches past the items that can match nothing.
+func (g *glob) skip(ends []int) {
+	for i, item := range g.items {
+		if ends[i] >= 0 && (item.optional || item.star) {
+			ends[i+1] = earlier(ends[i+1], ends[i])
+		}
+	}
+}
+
+// earlier returns the earlier of two match ends, where -1 is none.
+func earlier(a, b int) int {
+	if a < 0 || b >= 0 && b < a {
+		return b
+	}
+
+	return a
+}
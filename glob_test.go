@@ -0,0 +1,231 @@
+package bench
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// globMatches reports whether items match all of text, by plain recursion.
+func globMatches(items []globItem, text string) bool {
+	if len(items) == 0 {
+		return text == ""
+	}
+
+	item := items[0]
+	if item.star {
+		for i := 0; i <= len(text); i++ {
+			if globMatches(items[1:], text[i:]) {
+				return true
+			}
+			if i < len(text) && !item.set.has(text[i]) {
+				return false
+			}
+		}
+		return false
+	}
+
+	if item.optional && globMatches(items[1:], text) {
+		return true
+	}
+
+	return text != "" && item.set.has(text[0]) && globMatches(items[1:], text[1:])
+}
+
+// naiveGlob is the reference for glob matching: for every start position, the
+// shortest match starting there.
+func naiveGlob(items []globItem, line string) []hit {
+	var hits []hit
+	for start := 0; start < len(line); start++ {
+		for end := start + 1; end <= len(line); end++ {
+			if globMatches(items, line[start:end]) {
+				hits = append(hits, hit{start: start, end: end})
+				break
+			}
+		}
+	}
+
+	return hits
+}
+
+func TestFindWithOptions_glob(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Glob = true
+
+	for _, tc := range []struct{ s, want string }{
+		{"a?b", "1:0:3,1:1:3,1:10:3,1:11:3,6:1:3,6:2:3"},
+		{"[de]{2}", "1:6:2,1:7:2,1:8:2,1:16:2,1:17:2,1:18:2,6:9:2,6:10:2,6:11:2,6:12:2,6:13:2"},
+		// the shortest match starting at each "j" ends at the first "k"
+		{"j*k", "7:9:4,7:10:3,7:11:2"},
+		{"s?{0,2}t", "4:6:3,4:7:2,4:16:3,4:17:2,9:0:4,9:1:3,9:2:2"},
+		{"aa", want},
+	} {
+		got, err := FindWithOptions(path, tc.s, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("FindWithOptions(%q, %q) => %q, want %q", path, tc.s, got, tc.want)
+		}
+	}
+}
+
+func TestGlob_overlap(t *testing.T) {
+	const line = "ERR x ERR y code"
+
+	for _, tc := range []struct {
+		overlap bool
+		want    string
+	}{
+		{true, "1:0:16,1:6:10"},
+		{false, "1:0:16"},
+	} {
+		opts := DefaultOptions()
+		opts.Glob = true
+		opts.Overlap = tc.overlap
+		m, err := CompileWithOptions("ERR*code", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Format(m.FindBytes([]byte(line))); got != tc.want {
+			t.Errorf("overlap %v => %q, want %q", tc.overlap, got, tc.want)
+		}
+	}
+}
+
+func TestFindWithOptions_globBad(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Glob = true
+
+	for _, s := range []string{"[ab", "a\\", "[z-a]", "a{3,2}", "a{5000}"} {
+		if _, err := FindWithOptions(path, s, opts); err == nil {
+			t.Errorf("FindWithOptions(%q): some kind of error should be returned", s)
+		}
+	}
+}
+
+func Test_parseGlob(t *testing.T) {
+	for _, tc := range []struct {
+		glob    string
+		match   []string
+		nomatch []string
+	}{
+		{"ERR?? code [0-9][0-9]", []string{"ERR42 code 17", "ERRxy code 00"}, []string{"ERR4 code 17", "ERR42 code 1x"}},
+		{"[!a-c]x", []string{"dx", "zx"}, []string{"ax", "cx"}},
+		{"[]a]", []string{"]", "a"}, []string{"b"}},
+		{"a\\*b", []string{"a*b"}, []string{"ab", "axb"}},
+		{"a{2,}", []string{"aa", "aaaa"}, []string{"a"}},
+		{"a{x}", []string{"a{x}"}, []string{"a"}},
+		{"[0-9]{1,3}.", []string{"1.", "123."}, []string{".", "1234."}},
+	} {
+		items, err := parseGlob(tc.glob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range tc.match {
+			if !globMatches(items, s) {
+				t.Errorf("glob %q should match %q", tc.glob, s)
+			}
+		}
+		for _, s := range tc.nomatch {
+			if globMatches(items, s) {
+				t.Errorf("glob %q should not match %q", tc.glob, s)
+			}
+		}
+	}
+}
+
+func TestGlob_literal(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Glob = true
+
+	m, err := CompileWithOptions("a\\?b", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Algorithm() != KMP {
+		t.Errorf("a glob without wildcards uses %v, want %v", m.Algorithm(), KMP)
+	}
+	if got := m.Format(m.FindBytes([]byte("a?b ab"))); got != "1:0" {
+		t.Errorf("FindBytes => %q, want %q", got, "1:0")
+	}
+}
+
+func TestGlob_random(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	pieces := []string{"a", "b", "c", "?", "*", "[ab]", "[!a]", "a{1,3}", "?{0,2}", "b{2,}"}
+
+	for n := 0; n < 500; n++ {
+		var glob strings.Builder
+		for i := 1 + rnd.Intn(4); i > 0; i-- {
+			glob.WriteString(pieces[rnd.Intn(len(pieces))])
+		}
+		line := make([]byte, rnd.Intn(24))
+		for i := range line {
+			line[i] = "abc"[rnd.Intn(3)]
+		}
+
+		opts := DefaultOptions()
+		opts.Glob = true
+		opts.Algorithm = PikeVM
+		m, err := CompileWithOptions(glob.String(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items, _ := parseGlob(glob.String())
+
+		expected := naiveGlob(items, string(line))
+		got := m.FindBytes(line)
+		if len(got) != len(expected) {
+			t.Fatalf("%q in %q => %v, want %v", glob.String(), line, got, expected)
+		}
+		for i, match := range got {
+			if match.Col != expected[i].start || match.Len != expected[i].end-expected[i].start {
+				t.Fatalf("%q in %q => %v, want %v", glob.String(), line, got, expected)
+			}
+		}
+
+		// without overlap, the leftmost match and then the next one after it
+		var leftmost []hit
+		for _, h := range expected {
+			if len(leftmost) == 0 || h.start >= leftmost[len(leftmost)-1].end {
+				leftmost = append(leftmost, h)
+			}
+		}
+		opts.Overlap = false
+		if m, err = CompileWithOptions(glob.String(), opts); err != nil {
+			t.Fatal(err)
+		}
+		got = m.FindBytes(line)
+		if len(got) != len(leftmost) {
+			t.Fatalf("%q in %q, no overlap => %v, want %v", glob.String(), line, got, leftmost)
+		}
+		for i, match := range got {
+			if match.Col != leftmost[i].start || match.Len != leftmost[i].end-leftmost[i].start {
+				t.Fatalf("%q in %q, no overlap => %v, want %v", glob.String(), line, got, leftmost)
+			}
+		}
+	}
+}
+
+func TestGlob_longLine(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Glob = true
+
+	line := []byte(strings.Repeat("x", 3*searchWindow))
+	for _, col := range []int{0, searchWindow - 3, 2*searchWindow - 1} {
+		copy(line[col:], "ERR42")
+	}
+
+	for _, glob := range []string{"ERR[0-9]{2}", "ERR*2"} {
+		m, err := CompileWithOptions(glob, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "1:0:5,1:4093:5,1:8191:5"
+		if got := m.Format(m.FindBytes(line)); got != expected {
+			t.Errorf("%q: FindBytes => %q, want %q", glob, got, expected)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package bench
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Grapheme clusters, for GraphemeColumns and Options.WholeGraphemes: the
+// extended grapheme clusters of Unicode Standard Annex #29, which is what a
+// reader sees as one character, e.g. e followed by a combining accent, a
+// flag made of two regional indicators, or a family emoji joined with ZWJ.
+// Each byte that is not part of valid UTF-8 is a cluster of its own.
+//
+// The rules and properties are those of the Unicode version of the unicode
+// package, 17.0. The Grapheme_Cluster_Break property is derived from its
+// tables where it can be, which leaves only the few tables below, from
+// emoji-data.txt, GraphemeBreakProperty.txt and DerivedCoreProperties.txt.
+
+// gcb is the Grapheme_Cluster_Break property of a rune.
+type gcb uint8
+
+const (
+	gcbOther gcb = iota
+	gcbCR
+	gcbLF
+	gcbControl
+	gcbExtend
+	gcbZWJ
+	gcbRegionalIndicator
+	gcbPrepend
+	gcbSpacingMark
+	gcbL
+	gcbV
+	gcbT
+	gcbLV
+	gcbLVT
+	gcbExtPict // Extended_Pictographic, from emoji-data.txt, which is not a break property but is used like one
+)
+
+// extendedPictographic is Extended_Pictographic from emoji-data.txt.
+var extendedPictographic = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x00A9, 0x00A9, 1}, {0x00AE, 0x00AE, 1}, {0x203C, 0x203C, 1}, {0x2049, 0x2049, 1},
+		{0x2122, 0x2122, 1}, {0x2139, 0x2139, 1}, {0x2194, 0x2199, 1}, {0x21A9, 0x21AA, 1},
+		{0x231A, 0x231B, 1}, {0x2328, 0x2328, 1}, {0x23CF, 0x23CF, 1}, {0x23E9, 0x23F3, 1},
+		{0x23F8, 0x23FA, 1}, {0x24C2, 0x24C2, 1}, {0x25AA, 0x25AB, 1}, {0x25B6, 0x25B6, 1},
+		{0x25C0, 0x25C0, 1}, {0x25FB, 0x25FE, 1}, {0x2600, 0x2604, 1}, {0x260E, 0x260E, 1},
+		{0x2611, 0x2611, 1}, {0x2614, 0x2615, 1}, {0x2618, 0x2618, 1}, {0x261D, 0x261D, 1},
+		{0x2620, 0x2620, 1}, {0x2622, 0x2623, 1}, {0x2626, 0x2626, 1}, {0x262A, 0x262A, 1},
+		{0x262E, 0x262F, 1}, {0x2638, 0x263A, 1}, {0x2640, 0x2640, 1}, {0x2642, 0x2642, 1},
+		{0x2648, 0x2653, 1}, {0x265F, 0x2660, 1}, {0x2663, 0x2663, 1}, {0x2665, 0x2666, 1},
+		{0x2668, 0x2668, 1}, {0x267B, 0x267B, 1}, {0x267E, 0x267F, 1}, {0x2692, 0x2697, 1},
+		{0x2699, 0x2699, 1}, {0x269B, 0x269C, 1}, {0x26A0, 0x26A1, 1}, {0x26A7, 0x26A7, 1},
+		{0x26AA, 0x26AB, 1}, {0x26B0, 0x26B1, 1}, {0x26BD, 0x26BE, 1}, {0x26C4, 0x26C5, 1},
+		{0x26C8, 0x26C8, 1}, {0x26CE, 0x26CF, 1}, {0x26D1, 0x26D1, 1}, {0x26D3, 0x26D4, 1},
+		{0x26E9, 0x26EA, 1}, {0x26F0, 0x26F5, 1}, {0x26F7, 0x26FA, 1}, {0x26FD, 0x26FD, 1},
+		{0x2702, 0x2702, 1}, {0x2705, 0x2705, 1}, {0x2708, 0x270D, 1}, {0x270F, 0x270F, 1},
+		{0x2712, 0x2712, 1}, {0x2714, 0x2714, 1}, {0x2716, 0x2716, 1}, {0x271D, 0x271D, 1},
+		{0x2721, 0x2721, 1}, {0x2728, 0x2728, 1}, {0x2733, 0x2734, 1}, {0x2744, 0x2744, 1},
+		{0x2747, 0x2747, 1}, {0x274C, 0x274C, 1}, {0x274E, 0x274E, 1}, {0x2753, 0x2755, 1},
+		{0x2757, 0x2757, 1}, {0x2763, 0x2764, 1}, {0x2795, 0x2797, 1}, {0x27A1, 0x27A1, 1},
+		{0x27B0, 0x27B0, 1}, {0x27BF, 0x27BF, 1}, {0x2934, 0x2935, 1}, {0x2B05, 0x2B07, 1},
+		{0x2B1B, 0x2B1C, 1}, {0x2B50, 0x2B50, 1}, {0x2B55, 0x2B55, 1}, {0x3030, 0x3030, 1},
+		{0x303D, 0x303D, 1}, {0x3297, 0x3297, 1}, {0x3299, 0x3299, 1},
+	},
+	R32: []unicode.Range32{
+		{0x1F004, 0x1F004, 1}, {0x1F02C, 0x1F02F, 1}, {0x1F094, 0x1F09F, 1}, {0x1F0AF, 0x1F0B0, 1},
+		{0x1F0C0, 0x1F0C0, 1}, {0x1F0CF, 0x1F0D0, 1}, {0x1F0F6, 0x1F0FF, 1}, {0x1F170, 0x1F171, 1},
+		{0x1F17E, 0x1F17F, 1}, {0x1F18E, 0x1F18E, 1}, {0x1F191, 0x1F19A, 1}, {0x1F1AE, 0x1F1E5, 1},
+		{0x1F201, 0x1F20F, 1}, {0x1F21A, 0x1F21A, 1}, {0x1F22F, 0x1F22F, 1}, {0x1F232, 0x1F23A, 1},
+		{0x1F23C, 0x1F23F, 1}, {0x1F249, 0x1F25F, 1}, {0x1F266, 0x1F321, 1}, {0x1F324, 0x1F393, 1},
+		{0x1F396, 0x1F397, 1}, {0x1F399, 0x1F39B, 1}, {0x1F39E, 0x1F3F0, 1}, {0x1F3F3, 0x1F3F5, 1},
+		{0x1F3F7, 0x1F3FA, 1}, {0x1F400, 0x1F4FD, 1}, {0x1F4FF, 0x1F53D, 1}, {0x1F549, 0x1F54E, 1},
+		{0x1F550, 0x1F567, 1}, {0x1F56F, 0x1F570, 1}, {0x1F573, 0x1F57A, 1}, {0x1F587, 0x1F587, 1},
+		{0x1F58A, 0x1F58D, 1}, {0x1F590, 0x1F590, 1}, {0x1F595, 0x1F596, 1}, {0x1F5A4, 0x1F5A5, 1},
+		{0x1F5A8, 0x1F5A8, 1}, {0x1F5B1, 0x1F5B2, 1}, {0x1F5BC, 0x1F5BC, 1}, {0x1F5C2, 0x1F5C4, 1},
+		{0x1F5D1, 0x1F5D3, 1}, {0x1F5DC, 0x1F5DE, 1}, {0x1F5E1, 0x1F5E1, 1}, {0x1F5E3, 0x1F5E3, 1},
+		{0x1F5E8, 0x1F5E8, 1}, {0x1F5EF, 0x1F5EF, 1}, {0x1F5F3, 0x1F5F3, 1}, {0x1F5FA, 0x1F64F, 1},
+		{0x1F680, 0x1F6C5, 1}, {0x1F6CB, 0x1F6D2, 1}, {0x1F6D5, 0x1F6E5, 1}, {0x1F6E9, 0x1F6E9, 1},
+		{0x1F6EB, 0x1F6F0, 1}, {0x1F6F3, 0x1F6FF, 1}, {0x1F7DA, 0x1F7FF, 1}, {0x1F80C, 0x1F80F, 1},
+		{0x1F848, 0x1F84F, 1}, {0x1F85A, 0x1F85F, 1}, {0x1F888, 0x1F88F, 1}, {0x1F8AE, 0x1F8AF, 1},
+		{0x1F8BC, 0x1F8BF, 1}, {0x1F8C2, 0x1F8CF, 1}, {0x1F8D9, 0x1F8FF, 1}, {0x1F90C, 0x1F93A, 1},
+		{0x1F93C, 0x1F945, 1}, {0x1F947, 0x1F9FF, 1}, {0x1FA58, 0x1FA5F, 1}, {0x1FA6E, 0x1FAFF, 1},
+		{0x1FC00, 0x1FFFD, 1},
+	},
+}
+
+// graphemePrepend is Prepend from GraphemeBreakProperty.txt: the
+// Prepended_Concatenation_Mark runes, and a few whose Indic_Syllabic_Category
+// the unicode package does not have.
+var graphemePrepend = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x0600, 0x0605, 1}, {0x06DD, 0x06DD, 1}, {0x070F, 0x070F, 1}, {0x0890, 0x0891, 1},
+		{0x08E2, 0x08E2, 1}, {0x0D4E, 0x0D4E, 1},
+	},
+	R32: []unicode.Range32{
+		{0x110BD, 0x110BD, 1}, {0x110CD, 0x110CD, 1}, {0x111C2, 0x111C3, 1}, {0x113D1, 0x113D1, 1},
+		{0x1193F, 0x1193F, 1}, {0x11941, 0x11941, 1}, {0x11A84, 0x11A89, 1}, {0x11D46, 0x11D46, 1},
+		{0x11F02, 0x11F02, 1},
+	},
+}
+
+// incbConsonant is Indic_Conjunct_Break=Consonant from
+// DerivedCoreProperties.txt, for GB9c.
+var incbConsonant = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x0915, 0x0939, 1}, {0x0958, 0x095F, 1}, {0x0978, 0x097F, 1}, {0x0995, 0x09A8, 1},
+		{0x09AA, 0x09B0, 1}, {0x09B2, 0x09B2, 1}, {0x09B6, 0x09B9, 1}, {0x09DC, 0x09DD, 1},
+		{0x09DF, 0x09DF, 1}, {0x09F0, 0x09F1, 1}, {0x0A95, 0x0AA8, 1}, {0x0AAA, 0x0AB0, 1},
+		{0x0AB2, 0x0AB3, 1}, {0x0AB5, 0x0AB9, 1}, {0x0AF9, 0x0AF9, 1}, {0x0B15, 0x0B28, 1},
+		{0x0B2A, 0x0B30, 1}, {0x0B32, 0x0B33, 1}, {0x0B35, 0x0B39, 1}, {0x0B5C, 0x0B5D, 1},
+		{0x0B5F, 0x0B5F, 1}, {0x0B71, 0x0B71, 1}, {0x0C15, 0x0C28, 1}, {0x0C2A, 0x0C39, 1},
+		{0x0C58, 0x0C5A, 1}, {0x0D15, 0x0D3A, 1}, {0x1000, 0x102A, 1}, {0x103F, 0x103F, 1},
+		{0x1050, 0x1055, 1}, {0x105A, 0x105D, 1}, {0x1061, 0x1061, 1}, {0x1065, 0x1066, 1},
+		{0x106E, 0x1070, 1}, {0x1075, 0x1081, 1}, {0x108E, 0x108E, 1}, {0x1780, 0x17B3, 1},
+		{0x1A20, 0x1A54, 1}, {0x1B0B, 0x1B0C, 1}, {0x1B13, 0x1B33, 1}, {0x1B45, 0x1B4C, 1},
+		{0x1B83, 0x1BA0, 1}, {0x1BAE, 0x1BAF, 1}, {0x1BBB, 0x1BBD, 1}, {0xA989, 0xA98B, 1},
+		{0xA98F, 0xA9B2, 1}, {0xA9E0, 0xA9E4, 1}, {0xA9E7, 0xA9EF, 1}, {0xA9FA, 0xA9FE, 1},
+		{0xAA60, 0xAA6F, 1}, {0xAA71, 0xAA73, 1}, {0xAA7A, 0xAA7A, 1}, {0xAA7E, 0xAA7F, 1},
+		{0xAAE0, 0xAAEA, 1}, {0xABC0, 0xABDA, 1},
+	},
+	R32: []unicode.Range32{
+		{0x10A00, 0x10A00, 1}, {0x10A10, 0x10A13, 1}, {0x10A15, 0x10A17, 1}, {0x10A19, 0x10A35, 1},
+		{0x11103, 0x11126, 1}, {0x11144, 0x11144, 1}, {0x11147, 0x11147, 1}, {0x11380, 0x11389, 1},
+		{0x1138B, 0x1138B, 1}, {0x1138E, 0x1138E, 1}, {0x11390, 0x113B5, 1}, {0x11900, 0x11906, 1},
+		{0x11909, 0x11909, 1}, {0x1190C, 0x11913, 1}, {0x11915, 0x11916, 1}, {0x11918, 0x1192F, 1},
+		{0x11A00, 0x11A00, 1}, {0x11A0B, 0x11A32, 1}, {0x11A50, 0x11A50, 1}, {0x11A5C, 0x11A83, 1},
+		{0x11F04, 0x11F10, 1}, {0x11F12, 0x11F33, 1},
+	},
+}
+
+// incbLinker is Indic_Conjunct_Break=Linker: the viramas that join the
+// consonants on either side of them into a conjunct.
+var incbLinker = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x094D, 0x094D, 1}, {0x09CD, 0x09CD, 1}, {0x0ACD, 0x0ACD, 1}, {0x0B4D, 0x0B4D, 1},
+		{0x0C4D, 0x0C4D, 1}, {0x0D4D, 0x0D4D, 1}, {0x1039, 0x1039, 1}, {0x17D2, 0x17D2, 1},
+		{0x1A60, 0x1A60, 1}, {0x1B44, 0x1B44, 1}, {0x1BAB, 0x1BAB, 1}, {0xA9C0, 0xA9C0, 1},
+		{0xAAF6, 0xAAF6, 1},
+	},
+	R32: []unicode.Range32{
+		{0x10A3F, 0x10A3F, 1}, {0x11133, 0x11133, 1}, {0x113D0, 0x113D0, 1}, {0x1193E, 0x1193E, 1},
+		{0x11A47, 0x11A47, 1}, {0x11A99, 0x11A99, 1}, {0x11F42, 0x11F42, 1},
+	},
+}
+
+// notSpacingMark lists the spacing marks that UAX #29 leaves out of
+// SpacingMark.
+var notSpacingMark = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x102B, 0x102C, 1}, {0x1038, 0x1038, 1}, {0x1062, 0x1064, 1}, {0x1067, 0x106D, 1},
+		{0x1083, 0x1083, 1}, {0x1087, 0x108C, 1}, {0x108F, 0x108F, 1}, {0x109A, 0x109C, 1},
+		{0x1A61, 0x1A61, 1}, {0x1A63, 0x1A64, 1}, {0xAA7B, 0xAA7B, 1}, {0xAA7D, 0xAA7D, 1},
+	},
+	R32: []unicode.Range32{
+		{0x11720, 0x11721, 1},
+	},
+}
+
+// unassignedIgnorable lists the unassigned runes that are
+// Default_Ignorable_Code_Point, which are Control.
+var unassignedIgnorable = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x2065, 0x2065, 1}, {0xFFF0, 0xFFF8, 1},
+	},
+	R32: []unicode.Range32{
+		{0xE0000, 0xE0000, 1}, {0xE0002, 0xE001F, 1}, {0xE0080, 0xE00FF, 1}, {0xE01F0, 0xE0FFF, 1},
+	},
+}
+
+// graphemeBreak returns the Grapheme_Cluster_Break property of r, following
+// the definitions in table 2 of UAX #29.
+func graphemeBreak(r rune) gcb {
+	if r < utf8.RuneSelf {
+		switch {
+		case r == '\r':
+			return gcbCR
+		case r == '\n':
+			return gcbLF
+		case r < 0x20 || r == 0x7F:
+			return gcbControl
+		}
+		return gcbOther
+	}
+
+	switch {
+	case r >= 0xAC00 && r <= 0xD7A3:
+		// precomposed Hangul syllables, each either LV or LVT
+		if (r-0xAC00)%28 == 0 {
+			return gcbLV
+		}
+		return gcbLVT
+	case r >= 0x1100 && r <= 0x115F, r >= 0xA960 && r <= 0xA97C:
+		return gcbL
+	case r >= 0x1160 && r <= 0x11A7, r >= 0xD7B0 && r <= 0xD7C6,
+		r == 0x16D63, r >= 0x16D67 && r <= 0x16D6A: // Kirat Rai vowel signs
+		return gcbV
+	case r >= 0x11A8 && r <= 0x11FF, r >= 0xD7CB && r <= 0xD7FB:
+		return gcbT
+	case r == 0x200D:
+		return gcbZWJ
+	case unicode.Is(unicode.Regional_Indicator, r):
+		return gcbRegionalIndicator
+	case unicode.Is(extendedPictographic, r):
+		return gcbExtPict
+	case unicode.Is(graphemePrepend, r):
+		return gcbPrepend
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Other_Grapheme_Extend),
+		r >= 0x1F3FB && r <= 0x1F3FF: // emoji modifiers
+		return gcbExtend
+	case unicode.Is(unicode.Mc, r) && !unicode.Is(notSpacingMark, r), r == 0x0E33, r == 0x0EB3:
+		return gcbSpacingMark
+	case unicode.In(r, unicode.Cc, unicode.Cf, unicode.Zl, unicode.Zp, unassignedIgnorable):
+		return gcbControl
+	}
+
+	return gcbOther
+}
+
+// nextGrapheme returns the length in bytes of the grapheme cluster b starts
+// with, or 0 when b is empty.
+func nextGrapheme(b []byte) int {
+	if len(b) == 0 {
+		return 0
+	}
+
+	// ASCII followed by ASCII is a cluster of its own, except for CR LF
+	if b[0] < utf8.RuneSelf && (len(b) == 1 || b[1] < utf8.RuneSelf && (b[0] != '\r' || b[1] != '\n')) {
+		return 1
+	}
+
+	r, pos := utf8.DecodeRune(b)
+	if r == utf8.RuneError && pos == 1 {
+		return 1
+	}
+	prev := graphemeBreak(r)
+
+	// pict is set after Extended_Pictographic Extend*, for GB11, and ri counts
+	// the regional indicators so far, for GB12; for GB9c, conjunct is set
+	// after an InCB Consonant followed by InCB Extend and Linker runes, and
+	// linked once one of those is a Linker
+	pict := prev == gcbExtPict
+	ri := 0
+	if prev == gcbRegionalIndicator {
+		ri = 1
+	}
+	conjunct, linked := unicode.Is(incbConsonant, r), false
+
+	for pos < len(b) {
+		r, size := utf8.DecodeRune(b[pos:])
+		if r == utf8.RuneError && size == 1 {
+			break
+		}
+		next := graphemeBreak(r)
+		consonant := unicode.Is(incbConsonant, r)
+
+		if !graphemeJoins(prev, next, pict, ri, conjunct && linked && consonant) {
+			break
+		}
+
+		switch {
+		case next == gcbExtPict:
+			pict = true
+		case (next == gcbExtend || next == gcbZWJ) && prev != gcbZWJ && pict:
+		default:
+			pict = false
+		}
+		if next == gcbRegionalIndicator {
+			ri++
+		}
+		switch {
+		case consonant:
+			conjunct, linked = true, false
+		case conjunct && unicode.Is(incbLinker, r):
+			linked = true
+		case conjunct && (next == gcbExtend || next == gcbZWJ) && r != 0x200C:
+			// InCB Extend, which is every Extend rune but ZWNJ and the linkers
+		default:
+			conjunct = false
+		}
+		prev = next
+		pos += size
+	}
+
+	return pos
+}
+
+// graphemeJoins reports whether there is no break between a rune of break
+// property prev and one of next, by rules GB3 to GB13 of UAX #29. linked
+// says next is an InCB Consonant that GB9c joins to the conjunct before it.
+func graphemeJoins(prev, next gcb, pict bool, ri int, linked bool) bool {
+	switch {
+	case prev == gcbCR && next == gcbLF: // GB3
+		return true
+	case prev == gcbCR, prev == gcbLF, prev == gcbControl: // GB4
+		return false
+	case next == gcbCR, next == gcbLF, next == gcbControl: // GB5
+		return false
+	case prev == gcbL && (next == gcbL || next == gcbV || next == gcbLV || next == gcbLVT): // GB6
+		return true
+	case (prev == gcbLV || prev == gcbV) && (next == gcbV || next == gcbT): // GB7
+		return true
+	case (prev == gcbLVT || prev == gcbT) && next == gcbT: // GB8
+		return true
+	case next == gcbExtend, next == gcbZWJ: // GB9
+		return true
+	case next == gcbSpacingMark: // GB9a
+		return true
+	case prev == gcbPrepend: // GB9b
+		return true
+	case linked: // GB9c
+		return true
+	case prev == gcbZWJ && next == gcbExtPict: // GB11
+		return pict
+	case prev == gcbRegionalIndicator && next == gcbRegionalIndicator: // GB12, GB13
+		return ri%2 == 1
+	}
+
+	return false // GB999
+}
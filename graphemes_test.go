@@ -0,0 +1,139 @@
+package bench
+
+import "testing"
+
+// pathEmoji has lines of emoji joined with ZWJ, flags, skin tones, tag
+// sequences, combining accents, Hangul jamo and Devanagari.
+const pathEmoji = "./data-emoji.txt"
+
+func TestFindWithOptions_graphemes(t *testing.T) {
+	for _, tc := range []struct {
+		s       string
+		columns ColumnUnit
+		whole   bool
+		want    string
+	}{
+		// one woman on her own, one in a family and one with a skin tone
+		{"👩", ByteColumns, false, "1:7,1:34,1:46"},
+		{"👩", GraphemeColumns, false, "1:0,1:10,1:19"},
+		{"👩", ByteColumns, true, "1:34"},
+		{"👩", GraphemeColumns, true, "1:10"},
+
+		// the second half of one flag and the first half of the next
+		{"🇺🇸", GraphemeColumns, true, "2:7"},
+		{"🇵🇺", ByteColumns, false, "2:10"},
+		{"🇵🇺", GraphemeColumns, false, "2:6"},
+		{"🇵🇺", GraphemeColumns, true, ""},
+
+		// e followed by a combining acute accent is é
+		{"cafe", GraphemeColumns, false, "3:9"},
+		{"cafe", GraphemeColumns, true, ""},
+		{"e", GraphemeColumns, true, "1:29,1:43,3:19,4:3,4:14,4:20,6:10"},
+
+		{"❤", GraphemeColumns, true, "4:17"},
+		{"🏳", ByteColumns, true, ""},
+		{"‍", ByteColumns, false, "1:4,1:11,1:18,4:6,4:47"},
+		{"‍", ByteColumns, true, ""},
+
+		// jamo do not match the precomposed syllable they make up
+		{"한", GraphemeColumns, true, "5:0"},
+
+		// स्ते is one conjunct, joined by the virama
+		{"स", GraphemeColumns, false, "6:2"},
+		{"स", GraphemeColumns, true, ""},
+		{"स्ते", GraphemeColumns, true, "6:2"},
+		{"ते", GraphemeColumns, true, ""},
+	} {
+		opts := DefaultOptions()
+		opts.Columns = tc.columns
+		opts.WholeGraphemes = tc.whole
+
+		got, err := FindWithOptions(pathEmoji, tc.s, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("%q, columns %d, whole %v => %q, want %q", tc.s, tc.columns, tc.whole, got, tc.want)
+		}
+	}
+}
+
+func TestGraphemeColumns_len(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Columns = GraphemeColumns
+	opts.Regexp = true
+	opts.Overlap = false
+	m, err := CompileWithOptions(`family.*skin`, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err := m.FindFile(pathEmoji)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Col != 2 || matches[0].Len != 37 {
+		t.Errorf("got %+v, want col 2 len 37", matches)
+	}
+}
+
+func TestWholeGraphemes_overlap(t *testing.T) {
+	// without overlap, a match left out does not hide the ones it overlaps;
+	// U+0600 is a prefix that forms one cluster with the a after it
+	opts := DefaultOptions()
+	opts.Overlap = false
+	opts.WholeGraphemes = true
+	m, err := CompileWithOptions("aba", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Format(m.FindBytes([]byte("\u0600ababa"))); got != "1:4" {
+		t.Errorf("got %q, want %q", got, "1:4")
+	}
+}
+
+func Test_nextGrapheme(t *testing.T) {
+	// from GraphemeBreakTest.txt, and a few more
+	for _, tc := range []struct {
+		s    string
+		want []int
+	}{
+		{"\r\n", []int{2}},
+		{"\n\r", []int{1, 1}},
+		{"äb", []int{3, 1}},
+		{" ‍ن", []int{4, 2}},
+		{"ن‍ ", []int{5, 1}},
+		{"각", []int{9}},
+		{"각ᄀ", []int{6, 3}},
+		{"각ᆨᄀ", []int{6, 3}},
+		{"\U0001f1e6\U0001f1e7\U0001f1e8b", []int{8, 4, 1}},
+		{"a\U0001f1e6\U0001f1e7\U0001f1e8\U0001f1e9b", []int{1, 8, 8, 1}},
+		{"a\U0001f1e6\U0001f1e7‍\U0001f1e8b", []int{1, 11, 4, 1}},
+		{"aःb", []int{4, 1}},
+		{"a؀b", []int{1, 3}},
+		{"\U0001f476\U0001f3ff\U0001f476", []int{8, 4}},
+		{"a\U0001f3ff\U0001f476‍\U0001f6d1", []int{5, 11}},
+		{"\U0001f476\U0001f3ff̈‍\U0001f476\U0001f3ff", []int{21}},
+		{"\U0001f6d1‍\U0001f6d1", []int{11}},
+		{"a‍\U0001f6d1", []int{4, 4}},
+		{"✁‍✁", []int{6, 3}},
+		{"a‍✁", []int{4, 3}},
+		{"क्ते", []int{12}},
+		{"क्‍त", []int{12}},
+		{"क्‌त", []int{9, 3}},
+		{"कत", []int{3, 3}},
+		{"é\xffe", []int{3, 1, 1}},
+		{"\x81\x81", []int{1, 1}},
+		{"", nil},
+	} {
+		var got []int
+		for b := []byte(tc.s); len(b) > 0; {
+			n := nextGrapheme(b)
+			got = append(got, n)
+			b = b[n:]
+		}
+		if !equalInts(got, tc.want) {
+			t.Errorf("nextGrapheme(%+q) => %v, want %v", tc.s, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package bench
+
+// Boyer-Moore-Horspool algorithm, modified to return all occurrences, including
+// overlapping ones, in the same way as kmpSearch.
+// via: http://en.wikipedia.org/wiki/Boyer–Moore–Horspool_algorithm
+func horspoolSearch(skip *[256]int, word, line []byte, result []int) []int {
+	last := len(word) - 1
+
+	// "empty" the initial result by setting its length to zero
+	result = result[0:0]
+
+	for m := 0; m+last < len(line); m += skip[line[m+last]] {
+		i := last
+		for i >= 0 && word[i] == line[m+i] {
+			i--
+		}
+
+		if i < 0 {
+			// got a match; the skip for word[last] never passes an overlapping one
+			result = append(result, m)
+		}
+	}
+
+	return result
+}
+
+// builds the bad-character skip table for Boyer-Moore-Horspool string search:
+// how far the word can be moved along when the byte under its last position
+// is b.
+func horspoolBuildTable(word []byte) *[256]int {
+	var skip [256]int
+
+	for b := range skip {
+		skip[b] = len(word)
+	}
+
+	for i := 0; i < len(word)-1; i++ {
+		skip[word[i]] = len(word) - 1 - i
+	}
+
+	return &skip
+}
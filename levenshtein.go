@@ -0,0 +1,54 @@
+package bench
+
+// Sellers' algorithm, for finding a word of any length with up to k
+// substitutions, insertions or deletions. It computes the edit distance table
+// one column per byte of the line, where row i of the column is the distance
+// between word[:i] and the closest substring of the line ending at that byte.
+// Ukkonen's cut-off only computes rows up to the last one that is still at
+// most k, which makes the expected time O(k) per byte rather than O(len(word)).
+// As with bitapSearch, only the closest match is kept for each start.
+// via: http://en.wikipedia.org/wiki/Approximate_string_matching
+func levenshteinSearch(word []byte, k int, line []byte, buf *searchBuffer) []hit {
+	n := len(word)
+
+	// "empty" the initial result by setting its length to zero
+	buf.hits = buf.hits[0:0]
+
+	// distances over k are all stored as k+1, so rows past the cut-off can
+	// be left alone and still be correct
+	C := append(buf.col[0:0], make([]int, n+1)...)
+	for i := range C {
+		C[i] = min(i, k+1)
+	}
+	buf.col = C
+
+	// the last row that is at most k; it can grow by only one per column
+	lact := k
+	for j, b := range line {
+		diag := 0 // C[i-1] of the previous column; row 0 is always 0
+		for i := 1; i <= min(lact+1, n); i++ {
+			cost := diag
+			if word[i-1] != b {
+				cost++
+			}
+			diag = C[i]
+			C[i] = min(cost, diag+1, C[i-1]+1, k+1)
+		}
+
+		if lact < n && C[lact+1] <= k {
+			lact++
+		} else {
+			for C[lact] > k {
+				lact--
+			}
+		}
+
+		if lact == n {
+			start, dist := approxStart(word, line[:j+1], n+k, buf)
+			buf.hits = append(buf.hits, hit{start: start, end: j + 1, dist: dist})
+		}
+	}
+
+	buf.hits = closestPerStart(buf.hits)
+	return buf.hits
+}
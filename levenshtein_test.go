@@ -0,0 +1,122 @@
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestLevenshtein_random(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for _, alphabet := range []string{"ab", "abc", "abcdefghij"} {
+		for n := 0; n < 200; n++ {
+			word := make([]byte, 2+rnd.Intn(8))
+			for i := range word {
+				word[i] = alphabet[rnd.Intn(len(alphabet))]
+			}
+			line := make([]byte, rnd.Intn(40))
+			for i := range line {
+				line[i] = alphabet[rnd.Intn(len(alphabet))]
+			}
+
+			opts := DefaultOptions()
+			opts.Algorithm = Levenshtein
+			opts.MaxErrors = 1 + rnd.Intn(len(word)-1)
+			m, err := CompileWithOptions(string(word), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := naiveApprox(string(word), string(line), opts.MaxErrors)
+			got := m.FindBytes(line)
+			if !equalApprox(got, expected) {
+				t.Fatalf("%q in %q with %d errors => %v, want %v", word, line, opts.MaxErrors, got, expected)
+			}
+		}
+	}
+}
+
+func TestLevenshtein_longPattern(t *testing.T) {
+	sentence := "The quick brown fox jumps over the lazy dog, then naps in the warm afternoon sun."
+	typo := strings.Replace(strings.Replace(sentence, "quick", "quikc", 1), "lazy", "lazzy", 1)
+	input := "nothing here\n" + typo + "\n" + sentence + "\n"
+
+	opts := DefaultOptions()
+	opts.MaxErrors = 4
+	opts.Overlap = false
+	m, err := CompileWithOptions(sentence, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Algorithm() != Levenshtein {
+		t.Errorf("Algorithm() => %v, want %v", m.Algorithm(), Levenshtein)
+	}
+
+	// "quikc" is two substitutions away from "quick", "lazzy" one insertion
+	// from "lazy"
+	expected := fmt.Sprintf("2:0:%d:3,3:0:%d:0", len(typo), len(sentence))
+	if got := m.Format(m.FindBytes([]byte(input))); got != expected {
+		t.Errorf("FindBytes => %q, want %q", got, expected)
+	}
+}
+
+func TestFindWithOptions_best(t *testing.T) {
+	opts := DefaultOptions()
+	opts.MaxErrors = 1
+	opts.Best = 3
+
+	got, err := FindWithOptions(path, "aabbcc", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the two exact matches, then the match with one error
+	expected := "1:0:6:0,1:10:6:0,6:1:6:1"
+	if got != expected {
+		t.Errorf("FindWithOptions(%q, %q) => %q, want %q", path, "aabbcc", got, expected)
+	}
+}
+
+func TestFindWithOptions_bestMaxMatches(t *testing.T) {
+	opts := DefaultOptions()
+	opts.MaxErrors = 1
+	opts.Best = 2
+	opts.MaxMatches = 1
+
+	got, err := FindWithOptions(path, "aabbbc", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the exact match on line 6, not the first match found, on line 1
+	expected := "6:1:6:0"
+	if got != expected {
+		t.Errorf("FindWithOptions(%q, %q) => %q, want %q", path, "aabbbc", got, expected)
+	}
+}
+
+func Test_bestMatches(t *testing.T) {
+	best := &bestMatches{n: 3}
+	for i, dist := range []int{3, 1, 4, 1, 5, 0, 2} {
+		best.add(Match{Offset: int64(i), Dist: dist})
+	}
+
+	var got []Match
+	best.emit(func(match Match) bool {
+		got = append(got, match)
+		return true
+	})
+
+	expected := []Match{{Offset: 5, Dist: 0}, {Offset: 1, Dist: 1}, {Offset: 3, Dist: 1}}
+	if len(got) != len(expected) {
+		t.Fatalf("bestMatches => %v, want %v", got, expected)
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("bestMatches => %v, want %v", got, expected)
+			break
+		}
+	}
+}
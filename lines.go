@@ -0,0 +1,122 @@
+package bench
+
+import "bytes"
+
+// LineEnding selects what ends a line, and so how rows are counted.
+type LineEnding int
+
+const (
+	AutoEndings LineEnding = iota // \n or \r\n, as bufio.ScanLines takes them, or CREndings if the input has no \n at all
+	LFEndings                     // \n; a \r before it is part of the line
+	CRLFEndings                   // \r\n; a lone \n or \r is part of the line
+	CREndings                     // \r, as in old Mac files; a \n after it starts the next line
+	AnyEndings                    // \r\n, \n or \r
+
+	// lfOrCRLFEndings is what AutoEndings picks when the input has a \n: \n,
+	// or \r\n, as bufio.ScanLines takes them, which includes keeping a lone \r
+	// in its line and dropping a \r at the end of a last line that has no \n
+	lfOrCRLFEndings
+)
+
+// detectEndings returns the line endings that AutoEndings picks for the input
+// data starts, and whether data holds enough of it to tell. Lone \r only ends
+// lines in an input with no \n at all, as in old Mac files, and not in one
+// where a progress display has left a \r on the first line, so telling them
+// apart takes the whole input.
+func detectEndings(data []byte, atEOF bool) (LineEnding, bool) {
+	switch {
+	case bytes.IndexByte(data, '\n') >= 0:
+		return lfOrCRLFEndings, true
+	case bytes.IndexByte(data, '\r') >= 0:
+		return CREndings, atEOF
+	}
+
+	return lfOrCRLFEndings, atEOF
+}
+
+// lineEnd returns the offset of the first line ending in data and its length,
+// or -1 if data holds none yet. e must not be AutoEndings.
+func (e LineEnding) lineEnd(data []byte, atEOF bool) (int, int) {
+	switch e {
+	case LFEndings:
+		return bytes.IndexByte(data, '\n'), 1
+	case CRLFEndings:
+		return bytes.Index(data, []byte("\r\n")), 2
+	case CREndings:
+		return bytes.IndexByte(data, '\r'), 1
+	case AnyEndings:
+		i := bytes.IndexAny(data, "\r\n")
+		switch {
+		case i < 0 || data[i] == '\n':
+			return i, 1
+		case i+1 < len(data):
+			if data[i+1] == '\n' {
+				return i, 2
+			}
+			return i, 1
+		case atEOF:
+			return i, 1
+		}
+		// the \r may be followed by a \n that has not been read yet
+		return -1, 0
+	}
+
+	i := bytes.IndexByte(data, '\n')
+	if i > 0 && data[i-1] == '\r' {
+		return i - 1, 2
+	}
+	return i, 1
+}
+
+// lineSplitter is a bufio.SplitFunc that splits lines at the line endings
+// given, and remembers how far each line advanced the input, since the line
+// ending is not part of the line.
+type lineSplitter struct {
+	endings LineEnding
+
+	n          int  // length of the last line, ending included
+	terminated bool // whether the last line had an ending
+}
+
+func (s *lineSplitter) split(data []byte, atEOF bool) (int, []byte, error) {
+	if s.endings == AutoEndings {
+		endings, ok := detectEndings(data, atEOF)
+		if !ok {
+			return 0, nil, nil
+		}
+		s.endings = endings
+	}
+
+	if i, size := s.endings.lineEnd(data, atEOF); i >= 0 {
+		s.n, s.terminated = i+size, true
+		return i + size, data[:i], nil
+	}
+
+	// the last line need not have an ending
+	if atEOF && len(data) > 0 {
+		s.n, s.terminated = len(data), false
+		if s.endings == lfOrCRLFEndings && data[len(data)-1] == '\r' {
+			return len(data), data[:len(data)-1], nil
+		}
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
+
+// lineStarts returns the offset in input of the start of each line.
+func lineStarts(input []byte, endings LineEnding) []int {
+	if endings == AutoEndings {
+		endings, _ = detectEndings(input, true)
+	}
+
+	starts := []int{0}
+	for pos := 0; ; {
+		i, size := endings.lineEnd(input[pos:], true)
+		if i < 0 {
+			return starts
+		}
+		pos += i + size
+		starts = append(starts, pos)
+	}
+}
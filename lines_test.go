@@ -0,0 +1,201 @@
+package bench
+
+import (
+	"bufio"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// pathMixed has lines ended by \r\n, \n, \r and \r\r\n, and none at the end.
+const pathMixed = "./data-mixed.txt"
+
+// pathCR is data.txt with lone \r line endings.
+const pathCR = "./data-cr.txt"
+
+func TestFindWithOptions_lineEndings(t *testing.T) {
+	for _, tc := range []struct {
+		path    string
+		s       string
+		endings LineEnding
+		want    string
+	}{
+		{pathMixed, "aa", AutoEndings, "1:0,2:1,3:3,4:0"},
+		{pathMixed, "aa", LFEndings, "1:0,2:1,3:3,4:0"},
+		{pathMixed, "aa", CRLFEndings, "1:0,2:1,2:7,3:0"},
+		{pathMixed, "aa", CREndings, "1:0,2:2,3:0,5:1"},
+		{pathMixed, "aa", AnyEndings, "1:0,2:1,4:0,6:0"},
+
+		// a \r is only part of the line when it does not end it
+		{pathMixed, "b\r", AutoEndings, ""},
+		{pathMixed, "b\r", LFEndings, "1:2"},
+		{pathMixed, "b\r", CRLFEndings, ""},
+		{pathMixed, "a\r", AutoEndings, "3:4"},
+		{pathMixed, "a\r", LFEndings, "3:4"},
+		{pathMixed, "a\r", CRLFEndings, "2:8"},
+		{pathMixed, "\ny", CRLFEndings, "2:3"},
+
+		{pathCR, word, AutoEndings, want},
+		{pathCR, word, CREndings, want},
+		{pathCR, word, AnyEndings, want},
+		{pathCR, word, LFEndings, "1:0,1:10,1:105,1:106"},
+	} {
+		opts := DefaultOptions()
+		opts.LineEndings = tc.endings
+
+		got, err := FindWithOptions(tc.path, tc.s, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("%q in %s, endings %d => %q, want %q", tc.s, tc.path, tc.endings, got, tc.want)
+		}
+	}
+}
+
+func TestFindReader_strayCR(t *testing.T) {
+	// a progress display on the first line does not make \r end lines in a
+	// file that has \n, with or without Multiline
+	const input = "10%\r50%\rdone\nline2\nline3 aa\n"
+	got, err := FindReader(strings.NewReader(input), "aa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "3:6" {
+		t.Errorf("FindReader => %q, want %q", got, "3:6")
+	}
+
+	opts := DefaultOptions()
+	opts.Multiline = true
+	m, err := CompileWithOptions("done\nl", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Format(m.FindBytes([]byte(input))); got != "1:8-2:1" {
+		t.Errorf("multiline => %q, want %q", got, "1:8-2:1")
+	}
+}
+
+func TestLineEndings_offsets(t *testing.T) {
+	for _, endings := range []LineEnding{AutoEndings, LFEndings, CRLFEndings, CREndings, AnyEndings} {
+		opts := DefaultOptions()
+		opts.LineEndings = endings
+		m, err := CompileWithOptions("aa", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines, err := m.FindFile(pathMixed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// searching the whole input finds the same rows and columns, and both
+		// have the offsets of "aa" in the file
+		opts.Multiline = true
+		if m, err = CompileWithOptions("aa", opts); err != nil {
+			t.Fatal(err)
+		}
+		whole, err := m.FindFile(pathMixed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		offsets := []int64{0, 6, 12, 17}
+		if len(lines) != len(offsets) || len(whole) != len(offsets) {
+			t.Fatalf("endings %d: got %v and %v, want %d matches", endings, lines, whole, len(offsets))
+		}
+		for i := range lines {
+			if lines[i] != whole[i] || lines[i].Offset != offsets[i] {
+				t.Errorf("endings %d: match %d => %+v and %+v, want offset %d", endings, i, lines[i], whole[i], offsets[i])
+			}
+		}
+	}
+}
+
+func TestLineEndings_multiline(t *testing.T) {
+	for _, tc := range []struct {
+		s       string
+		endings LineEnding
+		want    string
+	}{
+		{"b\r\nx", AutoEndings, "1:2-2:1"},
+		{"b\r\nx", CREndings, "1:2-2:2"},
+		{"y\raa\r", AutoEndings, "3:1-3:6"},
+		{"y\raa\r", AnyEndings, "3:1-5:0"},
+		{"\r\r\na", CRLFEndings, "2:9-3:1"},
+	} {
+		opts := DefaultOptions()
+		opts.Multiline = true
+		opts.LineEndings = tc.endings
+
+		got, err := FindWithOptions(pathMixed, tc.s, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("%q, endings %d => %q, want %q", tc.s, tc.endings, got, tc.want)
+		}
+	}
+}
+
+func TestLineEndings_bad(t *testing.T) {
+	opts := DefaultOptions()
+	opts.LineEndings = AnyEndings + 1
+	if _, err := CompileWithOptions("aa", opts); err == nil {
+		t.Error("some kind of error should be returned")
+	}
+}
+
+func Test_lineSplitter(t *testing.T) {
+	// a \r at the end of one read may be followed by a \n in the next
+	for _, tc := range []struct {
+		endings LineEnding
+		input   string
+		want    []string
+	}{
+		{AutoEndings, "a\r\nb\r", []string{"a", "b"}},
+		{LFEndings, "a\nb\r", []string{"a", "b\r"}},
+		{AutoEndings, "a\rb\r\n", []string{"a\rb"}},
+		{AutoEndings, "a\rb\r", []string{"a", "b"}},
+		{AnyEndings, "a\r\nb\r\rc\n", []string{"a", "b", "", "c"}},
+		{CREndings, "a\r\nb", []string{"a", "\nb"}},
+		{CRLFEndings, "a\r\r\nb\r", []string{"a\r", "b\r"}},
+		{LFEndings, "", nil},
+	} {
+		lines := lineSplitter{endings: tc.endings}
+		scanner := bufio.NewScanner(iotest.OneByteReader(strings.NewReader(tc.input)))
+		scanner.Split(lines.split)
+
+		var got []string
+		for scanner.Scan() {
+			got = append(got, scanner.Text())
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("endings %d: %q => %q, want %q", tc.endings, tc.input, got, tc.want)
+		}
+	}
+}
+
+func Test_lineSplitter_scanLines(t *testing.T) {
+	// when there is a \n, AutoEndings splits lines as bufio.ScanLines does,
+	// whatever lone \r come before it
+	for _, input := range []string{"a\r\nb\r", "a\nb\r\n", "a\r\n\r\nb", "a\nb\r\r\n", "x\n\r", "a\rb\nc\r", "10%\r50%\rdone\nx"} {
+		var want []string
+		scanner := bufio.NewScanner(strings.NewReader(input))
+		for scanner.Scan() {
+			want = append(want, scanner.Text())
+		}
+
+		lines := lineSplitter{}
+		scanner = bufio.NewScanner(iotest.OneByteReader(strings.NewReader(input)))
+		scanner.Split(lines.split)
+		var got []string
+		for scanner.Scan() {
+			got = append(got, scanner.Text())
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%q => %q, want %q", input, got, want)
+		}
+	}
+}
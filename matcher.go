@@ -0,0 +1,778 @@
+package bench
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"os"
+	"regexp"
+	"slices"
+)
+
+// Matcher is a compiled set of search words. It holds the tables of its
+// search backend so that the same words can be searched for in many inputs
+// without rebuilding them. A Matcher is never modified after Compile returns,
+// so it is safe for concurrent use.
+type Matcher struct {
+	words  [][]byte
+	opts   Options
+	algo   Algorithm
+	reason string
+
+	// a match is at most span bytes long, or any length when span is -1.
+	// When matches can have different lengths, the backend is also shown
+	// lookbehind bytes before each window, so that it can tell where a match
+	// ending inside the window really starts.
+	span       int
+	lookbehind int
+
+	unordered bool         // hits have to be sorted before they are reported
+	resumes   bool         // search leaves out overlapping hits itself, so windows start at the last match's end
+	fields    formatFields // what Format shows besides row:col
+	wordBytes byteSet      // the bytes words are made of, for ASCIIWords and CustomWords
+
+	// search returns every occurrence of the words in line, using buf to
+	// hold the results
+	search func(line []byte, buf *searchBuffer) []hit
+
+	// transform, when set, returns the text that is searched instead of
+	// line, normalized and folded, along with the offset in line of each of
+	// its bytes, or nil offsets when they are the same
+	transform func(line []byte, buf *searchBuffer) ([]byte, []int)
+}
+
+// a hit is an occurrence found by a search backend, as byte offsets into the
+// searched line.
+type hit struct {
+	start, end int
+	pattern    int
+	dist       int
+}
+
+// searchBuffer holds the results of one window at a time. The single word
+// backends report start offsets only, which are turned into hits afterwards.
+// The approximate backends also keep their working state here.
+type searchBuffer struct {
+	cols []int
+	hits []hit
+
+	bits []uint64
+	row  []int
+	col  []int
+
+	normed   []byte
+	normOffs []int
+	folded   []byte
+	offs     []int
+}
+
+// wordHits turns the start offsets in buf.cols into hits of length n.
+func (buf *searchBuffer) wordHits(n int) []hit {
+	buf.hits = buf.hits[0:0]
+	for _, col := range buf.cols {
+		buf.hits = append(buf.hits, hit{start: col, end: col + n})
+	}
+
+	return buf.hits
+}
+
+// Compile prepares pattern for searching with DefaultOptions.
+func Compile(pattern string) (*Matcher, error) {
+	return CompileWithOptions(pattern, DefaultOptions())
+}
+
+// CompileWithOptions prepares pattern for searching with opts.
+func CompileWithOptions(pattern string, opts Options) (*Matcher, error) {
+	if pattern == "" {
+		return nil, errors.New("s cannot be empty")
+	}
+
+	return compile([]string{pattern}, opts)
+}
+
+// CompileAll prepares patterns for searching all at once with DefaultOptions.
+// Each Match reports which of the patterns was found.
+func CompileAll(patterns []string) (*Matcher, error) {
+	return CompileAllWithOptions(patterns, DefaultOptions())
+}
+
+// CompileAllWithOptions prepares patterns for searching all at once with opts.
+// The single word backends cannot do that, so when there is more than one
+// pattern, they are replaced by AhoCorasick.
+func CompileAllWithOptions(patterns []string, opts Options) (*Matcher, error) {
+	if len(patterns) == 0 {
+		return nil, errors.New("patterns cannot be empty")
+	}
+	for i, pattern := range patterns {
+		if pattern == "" {
+			return nil, fmt.Errorf("pattern %d cannot be empty", i)
+		}
+	}
+
+	return compile(patterns, opts)
+}
+
+func compile(patterns []string, opts Options) (*Matcher, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	if opts.Regexp {
+		switch {
+		case len(patterns) > 1:
+			return nil, errors.New("regular expression matching needs a single pattern")
+		case opts.Glob:
+			return nil, errors.New("a pattern cannot be both a glob and a regular expression")
+		case opts.MaxErrors > 0:
+			return nil, errors.New("regular expressions cannot be matched with errors")
+		}
+	}
+
+	// the patterns are normalized and folded here and each line as it is
+	// searched; regular expressions are used as written, and regexp folds by
+	// itself
+	if (opts.Normalize != NoNormalization || opts.Fold != NoFold) && !opts.Regexp {
+		prepared := make([]string, len(patterns))
+		for i, pattern := range patterns {
+			if opts.Normalize != NoNormalization {
+				pattern = normForms[opts.Normalize].String(pattern)
+			}
+			prepared[i] = foldString(pattern, opts.Fold)
+		}
+		patterns = prepared
+	}
+
+	// a glob without wildcards is searched for as a plain word
+	var items []globItem
+	if opts.Glob {
+		if len(patterns) > 1 {
+			return nil, errors.New("glob matching needs a single pattern")
+		}
+		if opts.MaxErrors > 0 {
+			return nil, errors.New("glob patterns cannot be matched with errors")
+		}
+
+		var err error
+		if items, err = parseGlob(patterns[0]); err != nil {
+			return nil, err
+		}
+		if word, ok := globLiteral(items); ok {
+			patterns, items = []string{string(word)}, nil
+		}
+	}
+
+	m := &Matcher{opts: opts, algo: opts.Algorithm, reason: "set by Options.Algorithm"}
+	switch opts.WholeWords {
+	case ASCIIWords:
+		m.wordBytes = asciiWordBytes
+	case CustomWords:
+		for i := 0; i < len(opts.WordBytes); i++ {
+			b := opts.WordBytes[i]
+			m.wordBytes.add(b)
+			if lower := asciiLower[b]; opts.Fold != NoFold && lower >= 'a' && lower <= 'z' {
+				// the searched text may or may not be folded
+				m.wordBytes.add(lower)
+				m.wordBytes.add(lower - 'a' + 'A')
+			}
+		}
+	}
+	for _, pattern := range patterns {
+		m.words = append(m.words, []byte(pattern))
+		m.span = max(m.span, len(pattern))
+	}
+	m.unordered = len(m.words) > 1
+	if len(m.words) > 1 {
+		m.fields = fmtPattern
+		for _, word := range m.words {
+			if len(word) != len(m.words[0]) {
+				m.fields |= fmtLen
+			}
+		}
+	}
+
+	switch {
+	case opts.Regexp:
+		m.algo = RE2
+		m.reason = "only RE2 matches regular expressions"
+	case items != nil:
+		m.algo = PikeVM
+		m.reason = "only PikeVM matches glob wildcards"
+	case opts.MaxErrors > 0:
+		if len(m.words) > 1 {
+			return nil, errors.New("approximate matching needs a single pattern")
+		}
+		if opts.MaxErrors >= len(m.words[0]) {
+			return nil, fmt.Errorf("MaxErrors must be less than the pattern length %d", len(m.words[0]))
+		}
+		if m.algo != Bitap && m.algo != Levenshtein {
+			if len(m.words[0]) <= bitapMaxLen {
+				m.algo = Bitap
+				m.reason = fmt.Sprintf("Bitap matches with errors in words of up to %d bytes", bitapMaxLen)
+			} else {
+				m.algo = Levenshtein
+				m.reason = fmt.Sprintf("word is longer than the %d bytes Bitap can handle", bitapMaxLen)
+			}
+		}
+
+		// with insertions a match can be longer than the word
+		m.span = len(m.words[0]) + opts.MaxErrors
+		m.lookbehind = m.span - 1
+		m.unordered = true
+		m.fields = fmtLen | fmtDist
+	case m.algo == Auto:
+		m.algo, m.reason = chooseAlgorithm(m.words)
+	case len(m.words) > 1 && !m.algo.multi():
+		m.algo = AhoCorasick
+		m.reason = fmt.Sprintf("%v cannot search for %d words at once", opts.Algorithm, len(m.words))
+	}
+
+	word := m.words[0]
+	switch m.algo {
+	case KMP:
+		T := kmpBuildTable(patterns[0])
+		if opts.Fold == FoldASCII {
+			m.search = func(line []byte, buf *searchBuffer) []hit {
+				buf.cols = kmpSearchFold(T, word, line, buf.cols)
+				return buf.wordHits(len(word))
+			}
+			break
+		}
+		// without overlap, KMP resumes after each match on its own, unless a
+		// match may still be left out afterwards and must not hide the others
+		if !opts.Overlap && opts.WholeWords == NoWordBoundary && !opts.WholeGraphemes {
+			m.resumes = true
+			m.search = func(line []byte, buf *searchBuffer) []hit {
+				buf.cols = kmpSearchNonOverlapping(T, word, line, buf.cols)
+				return buf.wordHits(len(word))
+			}
+			break
+		}
+		m.search = func(line []byte, buf *searchBuffer) []hit {
+			buf.cols = kmpSearch(T, word, line, buf.cols)
+			return buf.wordHits(len(word))
+		}
+	case Horspool:
+		skip := horspoolBuildTable(word)
+		m.search = func(line []byte, buf *searchBuffer) []hit {
+			buf.cols = horspoolSearch(skip, word, line, buf.cols)
+			return buf.wordHits(len(word))
+		}
+	case BoyerMoore:
+		skip := horspoolBuildTable(word)
+		gs := bmBuildGoodSuffixTable(word)
+		m.search = func(line []byte, buf *searchBuffer) []hit {
+			buf.cols = bmSearch(skip, gs, word, line, buf.cols)
+			return buf.wordHits(len(word))
+		}
+	case TwoWay:
+		ell, per := twoWayFactorize(word)
+		m.search = func(line []byte, buf *searchBuffer) []hit {
+			buf.cols = twoWaySearch(ell, per, word, line, buf.cols)
+			return buf.wordHits(len(word))
+		}
+	case AhoCorasick:
+		ac := acBuild(m.words)
+		m.search = func(line []byte, buf *searchBuffer) []hit {
+			buf.hits = acSearch(ac, line, buf.hits)
+			return buf.hits
+		}
+	case RabinKarp:
+		for i, word := range m.words {
+			if len(word) != len(m.words[0]) {
+				return nil, fmt.Errorf("RabinKarp needs patterns of the same length, pattern %d is not", i)
+			}
+		}
+		rk := rkBuild(m.words)
+		m.search = func(line []byte, buf *searchBuffer) []hit {
+			buf.hits = rkSearch(rk, line, buf.hits)
+			return buf.hits
+		}
+	case Bitap:
+		if len(m.words) > 1 {
+			return nil, errors.New("Bitap needs a single pattern")
+		}
+		if len(word) > bitapMaxLen {
+			return nil, fmt.Errorf("Bitap needs a pattern of at most %d bytes", bitapMaxLen)
+		}
+		bp := bitapBuild(word, opts.MaxErrors)
+		m.search = func(line []byte, buf *searchBuffer) []hit {
+			return bitapSearch(bp, line, buf)
+		}
+	case PikeVM:
+		if len(m.words) > 1 {
+			return nil, errors.New("PikeVM needs a single pattern")
+		}
+		if items == nil {
+			for _, b := range word {
+				item := globItem{}
+				item.set.add(b)
+				items = append(items, item)
+			}
+		}
+		g := globBuild(items)
+		m.search = func(line []byte, buf *searchBuffer) []hit {
+			return globSearch(g, line, buf)
+		}
+
+		m.span = globSpan(items)
+		m.fields = fmtLen
+	case RE2:
+		if len(m.words) > 1 {
+			return nil, errors.New("RE2 needs a single pattern")
+		}
+		pattern := patterns[0]
+		if !opts.Regexp {
+			pattern = regexp.QuoteMeta(pattern)
+		}
+		if opts.Fold != NoFold {
+			pattern = "(?i)" + pattern
+		}
+		if opts.Multiline && opts.Regexp {
+			pattern = "(?m)" + pattern
+		}
+		r, err := re2Build(pattern, opts.Overlap, opts.Leftmost == LeftmostLongest)
+		if err != nil {
+			return nil, err
+		}
+		m.search = func(line []byte, buf *searchBuffer) []hit {
+			return re2Search(r, line, buf)
+		}
+
+		m.span = -1
+		m.fields = fmtLen
+	case Levenshtein:
+		if len(m.words) > 1 {
+			return nil, errors.New("Levenshtein needs a single pattern")
+		}
+		k := opts.MaxErrors
+		m.search = func(line []byte, buf *searchBuffer) []hit {
+			return levenshteinSearch(word, k, line, buf)
+		}
+	}
+
+	fold := opts.Fold
+	if m.algo == RE2 || fold == FoldASCII && m.algo == KMP {
+		// the backend folds by itself
+		fold = NoFold
+	}
+	if opts.Normalize != NoNormalization || fold != NoFold {
+		m.transform = func(line []byte, buf *searchBuffer) ([]byte, []int) {
+			text, offs := line, []int(nil)
+			if opts.Normalize != NoNormalization {
+				// line is returned as it is when it is already normal, and
+				// must not end up in buf
+				normed, normOffs := normalize(buf.normed[0:0], buf.normOffs[0:0], normForms[opts.Normalize], line)
+				if normOffs != nil {
+					buf.normed, buf.normOffs = normed, normOffs
+				}
+				text, offs = normed, normOffs
+			}
+
+			switch fold {
+			case FoldASCII:
+				buf.folded = foldASCII(buf.folded[0:0], text)
+				text = buf.folded
+			case FoldUnicode:
+				buf.folded, buf.offs = foldUnicode(buf.folded[0:0], buf.offs[0:0], text)
+				if offs != nil {
+					for i, off := range buf.offs {
+						buf.offs[i] = offs[off]
+					}
+				}
+				text, offs = buf.folded, buf.offs
+			}
+
+			return text, offs
+		}
+	}
+
+	if opts.Multiline {
+		m.fields = fmtEnd | m.fields&(fmtDist|fmtPattern)
+	}
+
+	return m, nil
+}
+
+// Options returns the options m was compiled with.
+func (m *Matcher) Options() Options {
+	return m.opts
+}
+
+// Algorithm returns the backend m searches with. It differs from the one in
+// m's options when that was Auto, or could not search for all the patterns.
+func (m *Matcher) Algorithm() Algorithm {
+	return m.algo
+}
+
+// Reason says why m searches with the backend returned by Algorithm.
+func (m *Matcher) Reason() string {
+	return m.reason
+}
+
+// Format joins matches as row:col pairs, separated by m's Sep option. With
+// several patterns, each pair is followed by the index of the pattern found,
+// after the length when the patterns differ in length, as in 1:0:3:1.
+func (m *Matcher) Format(matches []Match) string {
+	return formatMatches(matches, m.opts.Sep, m.fields)
+}
+
+// FindFile returns every occurrence of the words in the file at path.
+func (m *Matcher) FindFile(path string) ([]Match, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return m.FindReader(file)
+}
+
+// FindBytes returns every occurrence of the words in b.
+func (m *Matcher) FindBytes(b []byte) []Match {
+	matches, _ := m.FindReader(bytes.NewReader(b))
+	return matches
+}
+
+// FindReader returns every occurrence of the words in r.
+func (m *Matcher) FindReader(r io.Reader) ([]Match, error) {
+	var matches []Match
+	err := m.FindFunc(r, func(match Match) bool {
+		matches = append(matches, match)
+		return true
+	})
+
+	return matches, err
+}
+
+// All returns an iterator over the occurrences of the words in r. Matches are
+// produced while r is being read, so stopping the loop early stops reading.
+func (m *Matcher) All(r io.Reader) iter.Seq2[Match, error] {
+	return func(yield func(Match, error) bool) {
+		stopped := false
+		err := m.FindFunc(r, func(match Match) bool {
+			stopped = !yield(match, nil)
+			return !stopped
+		})
+		if err != nil && !stopped {
+			yield(Match{}, err)
+		}
+	}
+}
+
+// searchWindow bounds how much of a line is searched in one go, so that a
+// line with a huge number of matches never has more than searchWindow of them
+// buffered before they are handed to the caller.
+const searchWindow = 4096
+
+// FindFunc calls fn for each occurrence of the words in r, in order, as soon
+// as it is found. It stops reading as soon as fn returns false. Lines may be
+// any length; an error reading r is returned along with the line it happened
+// on, once the matches before it have been passed to fn.
+func (m *Matcher) FindFunc(r io.Reader, fn func(Match) bool) error {
+	return m.FindFuncContext(context.Background(), r, fn)
+}
+
+// FindReaderContext is like FindReader, but gives up once ctx is done. The
+// matches found up to that point are returned along with ctx.Err().
+func (m *Matcher) FindReaderContext(ctx context.Context, r io.Reader) ([]Match, error) {
+	var matches []Match
+	err := m.FindFuncContext(ctx, r, func(match Match) bool {
+		matches = append(matches, match)
+		return true
+	})
+
+	return matches, err
+}
+
+// FindFuncContext is like FindFunc, but checks ctx between lines, between
+// windows of very long lines, and between the matches of a line that is
+// searched in one go, returning ctx.Err() once it is done. A Read that blocks
+// on r is not interrupted, nor is the search of a single window.
+//
+// With Options.Best set, the matches can only be ranked once all of r has
+// been read, so fn is not called until then, and Options.MaxMatches limits
+// how many of the ranked matches are reported rather than how many are read.
+func (m *Matcher) FindFuncContext(ctx context.Context, r io.Reader, fn func(Match) bool) error {
+	if m.opts.Best == 0 {
+		return m.scan(ctx, r, m.opts.MaxMatches, fn)
+	}
+
+	best := &bestMatches{n: m.opts.Best}
+	if m.opts.MaxMatches > 0 {
+		best.n = min(best.n, m.opts.MaxMatches)
+	}
+	err := m.scan(ctx, r, 0, best.add)
+	best.emit(fn)
+
+	return err
+}
+
+// scan reads r a line at a time, or a block of lines at a time with
+// Options.Multiline, and calls fn for every match, in order, stopping after
+// limit matches unless limit is 0.
+func (m *Matcher) scan(ctx context.Context, r io.Reader, limit int, fn func(Match) bool) error {
+	s := &lineScan{
+		m:     m,
+		ctx:   ctx,
+		done:  ctx.Done(),
+		fn:    fn,
+		limit: limit,
+		cc:    columnCounter{unit: m.opts.Columns},
+		ec:    columnCounter{unit: m.opts.Columns},
+		gc:    columnCounter{unit: GraphemeColumns},
+	}
+
+	if m.opts.Multiline {
+		return s.scanMultiline(r)
+	}
+
+	// lines are split without their endings, so the splitter remembers how
+	// far each line actually advanced the input, to keep absolute offsets right
+	lines := lineSplitter{endings: m.opts.LineEndings, terminated: true}
+	scanner := bufio.NewScanner(r)
+	scanner.Split(lines.split)
+
+	// lines may be any length: the buffer grows to hold the longest one
+	scanner.Buffer(nil, math.MaxInt)
+
+	row := m.opts.RowBase
+	var offset int64
+	for scanner.Scan() {
+		if isDone(s.done) {
+			return ctx.Err()
+		}
+
+		line := scanner.Bytes()
+		more, err := s.search(line, row, offset, 0, len(line))
+		if !more || err != nil {
+			return err
+		}
+
+		offset += int64(lines.n)
+		row++
+	}
+
+	if err := scanner.Err(); err != nil {
+		// an unterminated line before the error has been searched already, but
+		// it is the one that could not be read to its end
+		if !lines.terminated {
+			row--
+		}
+		return fmt.Errorf("reading line %d: %w", row, err)
+	}
+
+	return nil
+}
+
+// lineScan holds what a scan keeps from one line to the next.
+type lineScan struct {
+	m     *Matcher
+	ctx   context.Context
+	done  <-chan struct{}
+	fn    func(Match) bool
+	buf   searchBuffer
+	found int
+	limit int
+
+	cc columnCounter // counts the columns matches start at, and their lengths
+	ec columnCounter // counts the columns matches end at, with Options.Multiline
+	gc columnCounter // finds the grapheme clusters of the line, for WholeGraphemes
+
+	// with Options.Multiline, a block of rows is searched as one line, starts
+	// holds the offset of each row in it, and cc and ec count the columns of
+	// rows ccRow and ecRow
+	starts       []int
+	ccRow, ecRow int
+
+	// without overlap, no match may start before next, an offset in the input
+	next int64
+
+	line []byte
+	row  int
+}
+
+// search calls fn for every match that starts in line[first:last], where line
+// is the given row, or the first of its rows with Options.Multiline, and
+// starts at offset in the input. It returns false once no more matches are
+// wanted.
+func (s *lineScan) search(line []byte, row int, offset int64, first, last int) (bool, error) {
+	m := s.m
+	s.line, s.row = line, row
+	if s.starts == nil {
+		s.cc.reset(line)
+	}
+	s.gc.reset(line)
+
+	// columns are offsets into text until they are reported
+	text, offs := line, []int(nil)
+	if m.transform != nil {
+		text, offs = m.transform(line, &s.buf)
+	}
+
+	first, last = textOffset(offs, first), textOffset(offs, last)
+	nextCol := 0
+	if s.next > offset {
+		nextCol = textOffset(offs, int(s.next-offset))
+	}
+
+	// a match starting inside a window may run up to span-1 bytes past it;
+	// when there is no limit, the line is searched in one go
+	window := searchWindow
+	if m.span < 0 {
+		window = len(text)
+	}
+
+	for start := first; start < last; start += window {
+		if start > first && isDone(s.done) {
+			return false, s.ctx.Err()
+		}
+
+		from := max(start-m.lookbehind, 0)
+		if m.resumes {
+			from = max(from, nextCol)
+		}
+		end := len(text)
+		if m.span >= 0 {
+			end = min(start+window+m.span-1, len(text))
+		}
+		hits := m.search(text[from:end], &s.buf)
+		if m.unordered {
+			m.sortHits(hits)
+		}
+
+		for _, h := range hits {
+			// with no windows, a long line is only cut short between its hits
+			if m.span < 0 && isDone(s.done) {
+				return false, s.ctx.Err()
+			}
+
+			col, hitEnd := from+h.start, from+h.end
+			if col < start || col >= min(start+window, last) {
+				// another window reports this one
+				continue
+			}
+
+			// without overlap, the next match may not start before nextCol
+			if !m.opts.Overlap && col < nextCol {
+				continue
+			}
+
+			// words are told apart in the text as it was searched, where an
+			// accent that NFD splits off still follows its letter
+			if m.opts.WholeWords != NoWordBoundary && !m.wholeWord(text, col, hitEnd) {
+				continue
+			}
+
+			lo, hi := col, hitEnd
+			if offs != nil {
+				lo, hi = offs[col], endOffset(offs, hitEnd)
+			}
+			if m.opts.WholeGraphemes && !s.gc.aligned(lo, hi) {
+				continue
+			}
+			if !m.opts.Overlap {
+				nextCol, s.next = hitEnd, offset+int64(hi)
+			}
+
+			match := Match{
+				Offset:  offset + int64(lo),
+				Pattern: h.pattern,
+				Dist:    h.dist,
+			}
+			s.place(&match, lo, hi)
+			if !s.fn(match) {
+				return false, nil
+			}
+
+			s.found++
+			if s.found == s.limit {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// place sets the row and column match starts and ends at, and its length,
+// from the offsets in the line it starts and ends at.
+func (s *lineScan) place(match *Match, lo, hi int) {
+	opts := &s.m.opts
+	if s.starts == nil {
+		col, n := lo, hi-lo
+		if opts.Columns != ByteColumns {
+			col, n = s.cc.count(lo), s.cc.length(lo, hi)
+		}
+		match.Row, match.Col, match.Len = s.row, col+opts.ColBase, n
+		match.EndRow, match.EndCol = s.row, col+n+opts.ColBase
+		return
+	}
+
+	row, endRow := s.rowOf(lo), s.rowOf(hi)
+	start, endStart := s.starts[row], s.starts[endRow]
+	col, n, endCol := lo-start, hi-lo, hi-endStart
+	if opts.Columns != ByteColumns {
+		// the rows are counted from where they start to the end of the input,
+		// so that the length of a match takes in the rows after its first
+		if row != s.ccRow {
+			s.cc.reset(s.line[start:])
+			s.ccRow = row
+		}
+		if endRow != s.ecRow {
+			s.ec.reset(s.line[endStart:])
+			s.ecRow = endRow
+		}
+		col, n = s.cc.count(lo-start), s.cc.length(lo-start, hi-start)
+		endCol = s.ec.end(hi - endStart)
+	}
+
+	match.Row, match.Col, match.Len = s.row+row, col+opts.ColBase, n
+	match.EndRow, match.EndCol = s.row+endRow, endCol+opts.ColBase
+}
+
+// rowOf returns the row of the block searched, counted from 0, that offset
+// off of it is in.
+func (s *lineScan) rowOf(off int) int {
+	row, found := slices.BinarySearch(s.starts, off)
+	if !found {
+		row--
+	}
+
+	return row
+}
+
+// sortHits puts hits in the order they are reported: by where they start,
+// then by which one is preferred when they cannot all be reported, which is
+// the one with the fewest errors, then the one picked by Options.Leftmost.
+func (m *Matcher) sortHits(hits []hit) {
+	slices.SortFunc(hits, func(a, b hit) int {
+		if a.start != b.start {
+			return a.start - b.start
+		}
+		if a.dist != b.dist {
+			return a.dist - b.dist
+		}
+		if m.opts.Leftmost == LeftmostLongest && a.end != b.end {
+			return b.end - a.end
+		}
+		if a.pattern != b.pattern {
+			return a.pattern - b.pattern
+		}
+		return a.end - b.end
+	})
+}
+
+// isDone reports whether done is closed, without blocking.
+func isDone(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,250 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+)
+
+func TestCompile_emptyWord(t *testing.T) {
+	if _, err := Compile(""); err == nil {
+		t.Error("some kind of error should be returned")
+	}
+}
+
+func TestMatcher_FindFile(t *testing.T) {
+	m, err := Compile(word)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct{ path, want string }{{path, want}, {pathLarge, wantLarge}} {
+		matches, err := m.FindFile(tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := formatMatches(matches, ",", fmtRowCol); got != tc.want {
+			t.Errorf("FindFile(%q) => %q, want %q", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestMatcher_FindBytes(t *testing.T) {
+	m, err := Compile("ab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := formatMatches(m.FindBytes([]byte("abab\nxab\r\nab")), ",", fmtRowCol)
+	expected := "1:0,1:2,2:1,3:0"
+	if got != expected {
+		t.Errorf("FindBytes => %q, want %q", got, expected)
+	}
+}
+
+func TestMatcher_concurrent(t *testing.T) {
+	m, err := Compile(word)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			matches, err := m.FindFile(pathLarge)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if got := formatMatches(matches, ",", fmtRowCol); got != wantLarge {
+				t.Errorf("FindFile(%q) => %q, want %q", pathLarge, got, wantLarge)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMatcher_FindFunc_stop(t *testing.T) {
+	m, err := Compile(word)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Match
+	err = m.FindFunc(strings.NewReader("aaaa\naa"), func(match Match) bool {
+		got = append(got, match)
+		return len(got) < 2
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := formatMatches(got, ",", fmtRowCol); s != "1:0,1:1" {
+		t.Errorf("FindFunc stopped after %q, want %q", s, "1:0,1:1")
+	}
+}
+
+func TestMatcher_All(t *testing.T) {
+	m, err := Compile(word)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Match
+	for match, err := range m.All(strings.NewReader("aaaa\naa")) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, match)
+		if len(got) == 3 {
+			break
+		}
+	}
+	if s := formatMatches(got, ",", fmtRowCol); s != "1:0,1:1,1:2" {
+		t.Errorf("All yielded %q, want %q", s, "1:0,1:1,1:2")
+	}
+}
+
+func TestMatcher_FindBytes_longLine(t *testing.T) {
+	m, err := Compile("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// put matches on both sides of every window boundary
+	line := []byte(strings.Repeat("x", 3*searchWindow))
+	var expected []int
+	for _, col := range []int{0, searchWindow - 2, searchWindow + 1, 2*searchWindow - 1, 3*searchWindow - 3} {
+		copy(line[col:], "abc")
+		expected = append(expected, col)
+	}
+
+	got := m.FindBytes(line)
+	if len(got) != len(expected) {
+		t.Fatalf("FindBytes found %d matches, want %d", len(got), len(expected))
+	}
+	for i, match := range got {
+		if match.Col != expected[i] {
+			t.Errorf("match %d at col %d, want %d", i, match.Col, expected[i])
+		}
+	}
+}
+
+func TestMatcher_FindReader_hugeLine(t *testing.T) {
+	m, err := Compile("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// far longer than bufio.Scanner allows by default
+	line := strings.Repeat("x", 4<<20)
+	got, err := m.FindReader(strings.NewReader("abc" + line + "abc\nabc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := formatMatches(got, ",", fmtRowCol); s != "1:0,1:4194307,2:0" {
+		t.Errorf("FindReader => %q, want %q", s, "1:0,1:4194307,2:0")
+	}
+	if last := got[len(got)-1]; last.Offset != 4<<20+7 {
+		t.Errorf("last match at offset %d, want %d", last.Offset, 4<<20+7)
+	}
+}
+
+func TestMatcher_FindReader_readError(t *testing.T) {
+	errRead := errors.New("read failed")
+
+	// the rows read before the error are searched, in multiline mode too,
+	// whether it reads a block at a time or, with a regexp, all at once
+	for _, tc := range []struct {
+		input, want, msg  string
+		multiline, regexp bool
+	}{
+		{"aa\nxaa\n", "1:0,2:1", "reading line 3: read failed", false, false},
+		{"aa\nxaa", "1:0,2:1", "reading line 2: read failed", false, false},
+		{"aa\nxaa\n", "1:0,2:1", "reading line 3: read failed", true, false},
+		{"aa\nxaa", "1:0,2:1", "reading line 2: read failed", true, false},
+		{"aa\nxaa\n", "1:0,2:1", "reading line 3: read failed", true, true},
+		{"aa\nxaa", "1:0,2:1", "reading line 2: read failed", true, true},
+	} {
+		opts := DefaultOptions()
+		opts.Multiline = tc.multiline
+		opts.Regexp = tc.regexp
+		m, err := CompileWithOptions(word, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := m.FindReader(io.MultiReader(strings.NewReader(tc.input), iotest.ErrReader(errRead)))
+		if !errors.Is(err, errRead) || err.Error() != tc.msg {
+			t.Errorf("%q: FindReader returned error %v, want %q", tc.input, err, tc.msg)
+		}
+		if s := formatMatches(got, ",", fmtRowCol); s != tc.want {
+			t.Errorf("%q: FindReader => %q before the error, want %q", tc.input, s, tc.want)
+		}
+	}
+}
+
+func TestMatcher_FindFuncContext_canceled(t *testing.T) {
+	m, err := Compile("ab")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// cancel from inside the callback, at the start of a long line
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	line := strings.Repeat("ab", searchWindow)
+
+	var got []Match
+	err = m.FindFuncContext(ctx, strings.NewReader("ab\n"+line+"\nab"), func(match Match) bool {
+		got = append(got, match)
+		if match.Row == 2 && match.Col == 0 {
+			cancel()
+		}
+		return true
+	})
+	if err != context.Canceled {
+		t.Fatalf("FindFuncContext returned error %v, want %v", err, context.Canceled)
+	}
+
+	last := got[len(got)-1]
+	if last.Row != 2 || last.Col >= searchWindow {
+		t.Errorf("search went on to %v after cancellation", last)
+	}
+}
+
+func TestMatcher_FindFuncContext_canceledUnbounded(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Regexp = true
+	m, err := CompileWithOptions("ab+", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a regexp match can be of any length, so the line is one window
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	line := strings.Repeat("ab", searchWindow)
+
+	var got []Match
+	err = m.FindFuncContext(ctx, strings.NewReader("ab\n"+line+"\nab"), func(match Match) bool {
+		got = append(got, match)
+		if match.Row == 2 && match.Col == 0 {
+			cancel()
+		}
+		return true
+	})
+	if err != context.Canceled {
+		t.Fatalf("FindFuncContext returned error %v, want %v", err, context.Canceled)
+	}
+
+	last := got[len(got)-1]
+	if last.Row != 2 || last.Col != 0 {
+		t.Errorf("search went on to %v after cancellation", last)
+	}
+}
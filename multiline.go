@@ -0,0 +1,151 @@
+package bench
+
+import (
+	"fmt"
+	"io"
+	"slices"
+)
+
+// multilineChunk is how much of the input a multiline scan reads before it
+// searches what it has read.
+const multilineChunk = 64 << 10
+
+// scanMultiline searches r as a whole for Options.Multiline. When a match can
+// be of any length, all of r is read first. Otherwise r is searched a block
+// of rows at a time, and only the rows followed by at least span bytes of
+// whole rows are searched for where matches start; those bytes are searched
+// again with the next block, along with enough of the rows before it to
+// cover the lookbehind. Blocks begin and end on row boundaries, where
+// normalization, grapheme clusters and column counts all start over.
+func (s *lineScan) scanMultiline(r io.Reader) error {
+	m := s.m
+	if m.span < 0 {
+		// what was read before an error is searched as if the input ended there
+		input, readErr := io.ReadAll(r)
+		if isDone(s.done) {
+			return s.ctx.Err()
+		}
+
+		s.starts = lineStarts(input, m.opts.LineEndings)
+		s.ccRow, s.ecRow = -1, -1
+		more, err := s.search(input, m.opts.RowBase, 0, 0, len(input))
+		if more && err == nil && readErr != nil {
+			err = fmt.Errorf("reading line %d: %w", m.opts.RowBase+len(s.starts)-1, readErr)
+		}
+		return err
+	}
+
+	// buf holds the input from offset on, which is the start of row; starts
+	// holds where each row of buf starts, the last one maybe not read to its
+	// end yet, first where the rows not searched for matches yet start,
+	// checked how much of buf has been looked through for line endings, and
+	// noLF how much of it is known to hold no \n while they are not known
+	var (
+		buf     []byte
+		offset  int64
+		row     = m.opts.RowBase
+		starts  = []int{0}
+		first   int
+		checked int
+		noLF    int
+		endings = m.opts.LineEndings
+	)
+	for {
+		if isDone(s.done) {
+			return s.ctx.Err()
+		}
+
+		buf = slices.Grow(buf, multilineChunk)
+		// after an error, what has been read is searched as if the input
+		// ended there, before the error is returned
+		n, readErr := r.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		eof := readErr != nil
+		if readErr == io.EOF {
+			readErr = nil
+		}
+
+		// until a \n turns up, only what was just read needs looking through,
+		// but at the end all of buf does, for a \r
+		if endings == AutoEndings {
+			from := noLF
+			if eof {
+				from = 0
+			}
+			detected, ok := detectEndings(buf[from:], eof)
+			if !ok {
+				noLF = len(buf)
+				continue
+			}
+			endings = detected
+		}
+		for {
+			from := max(starts[len(starts)-1], checked)
+			i, size := endings.lineEnd(buf[from:], eof)
+			if i < 0 {
+				break
+			}
+			starts = append(starts, from+i+size)
+		}
+		checked = max(len(buf)-1, starts[len(starts)-1])
+
+		if !eof && len(buf)-first < multilineChunk {
+			continue
+		}
+
+		// the block ends with the last whole row, and matches are only looked
+		// for up to the rows after last that hold span bytes
+		end, last := len(buf), len(buf)
+		if !eof {
+			end = starts[len(starts)-1]
+			j := len(starts) - 2
+			for j >= 0 && starts[j] > first && s.textLen(buf[starts[j]:end]) < m.span {
+				j--
+			}
+			if j < 0 || starts[j] <= first {
+				continue
+			}
+			last = starts[j]
+		}
+
+		s.starts = starts
+		s.ccRow, s.ecRow = -1, -1
+		more, err := s.search(buf[:end], row, offset, first, last)
+		if !more || err != nil {
+			return err
+		}
+		if eof {
+			if readErr != nil {
+				return fmt.Errorf("reading line %d: %w", row+len(starts)-1, readErr)
+			}
+			return nil
+		}
+
+		// keep the rows before last that hold the lookbehind, and at least the
+		// line ending before it, which tells a whole word or grapheme cluster
+		j := slices.Index(starts, last)
+		for j > 0 && s.textLen(buf[starts[j]:last]) < max(m.lookbehind, 1) {
+			j--
+		}
+		drop := starts[j]
+		buf = buf[:copy(buf, buf[drop:])]
+		starts = starts[:copy(starts, starts[j:])]
+		for i := range starts {
+			starts[i] -= drop
+		}
+		offset += int64(drop)
+		row += j
+		first, checked = last-drop, checked-drop
+	}
+}
+
+// textLen returns the length of b once it is normalized and folded to be
+// searched.
+func (s *lineScan) textLen(b []byte) int {
+	if s.m.transform == nil {
+		return len(b)
+	}
+
+	text, _ := s.m.transform(b, &s.buf)
+	return len(text)
+}
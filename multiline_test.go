@@ -0,0 +1,174 @@
+package bench
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestFindWithOptions_multiline(t *testing.T) {
+	for _, tc := range []struct {
+		s      string
+		regexp bool
+		want   string
+	}{
+		{"ee\nff", false, "1:18-2:2"},
+		{"jj\nkk", false, "2:18-3:2"},
+		{"ee\n", false, "1:18-2:0"},
+		{"aa", false, "1:0-1:2,1:10-1:12,6:0-6:2,6:1-6:3"},
+
+		// across several lines, and with ^ and $ at each of them
+		{`ee\n(?:.*\n){3}uu`, true, "1:18-5:2"},
+		{`^aa`, true, "1:0-1:2,6:0-6:2"},
+		{`l$\n^m`, true, "7:17-8:1"},
+		{`yy.aaa`, true, ""},
+		{`(?s)yy.aaa`, true, "5:18-6:3"},
+	} {
+		opts := DefaultOptions()
+		opts.Multiline = true
+		opts.Regexp = tc.regexp
+
+		got, err := FindWithOptions(path, tc.s, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("%q => %q, want %q", tc.s, got, tc.want)
+		}
+
+		// a line at a time, a match cannot take in a newline
+		if !strings.Contains(tc.s, "\n") {
+			continue
+		}
+		opts.Multiline = false
+		if got, err = FindWithOptions(path, tc.s, opts); err != nil {
+			t.Fatal(err)
+		}
+		if got != "" {
+			t.Errorf("%q, not multiline => %q, want %q", tc.s, got, "")
+		}
+	}
+}
+
+func TestMultiline_columns(t *testing.T) {
+	const line = "añb\nñc\n"
+
+	for _, tc := range []struct {
+		columns ColumnUnit
+		want    Match
+	}{
+		{ByteColumns, Match{Row: 1, Col: 3, Offset: 3, Len: 4, EndRow: 2, EndCol: 2}},
+		{RuneColumns, Match{Row: 1, Col: 2, Offset: 3, Len: 3, EndRow: 2, EndCol: 1}},
+		{GraphemeColumns, Match{Row: 1, Col: 2, Offset: 3, Len: 3, EndRow: 2, EndCol: 1}},
+	} {
+		opts := DefaultOptions()
+		opts.Multiline = true
+		opts.Columns = tc.columns
+		for _, algo := range algorithms {
+			opts.Algorithm = algo
+			m, err := CompileWithOptions("b\nñ", opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := m.FindBytes([]byte(line))
+			if len(got) != 1 || got[0] != tc.want {
+				t.Errorf("%v, columns %d => %+v, want %+v", algo, tc.columns, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestMultiline_manyRows(t *testing.T) {
+	// matches run across window boundaries as well as rows
+	input := strings.Repeat("ab\n", 3000)
+	opts := DefaultOptions()
+	opts.Multiline = true
+	opts.RowBase, opts.ColBase = 0, 1
+	for _, algo := range algorithms {
+		opts.Algorithm = algo
+		m, err := CompileWithOptions("b\na", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := m.FindBytes([]byte(input))
+		if len(got) != 2999 {
+			t.Fatalf("%v: got %d matches, want %d", algo, len(got), 2999)
+		}
+		for i, match := range got {
+			want := Match{Row: i, Col: 2, Offset: int64(3*i + 1), Len: 3, EndRow: i + 1, EndCol: 2}
+			if match != want {
+				t.Fatalf("%v: match %d => %+v, want %+v", algo, i, match, want)
+			}
+		}
+	}
+}
+
+func TestMultiline_maxErrors(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Multiline = true
+	opts.MaxErrors = 1
+	opts.Overlap = false
+	m, err := CompileWithOptions("ee\nfg", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err := m.FindFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Format(matches); got != "1:18-2:1:1" {
+		t.Errorf("got %q, want %q", got, "1:18-2:1:1")
+	}
+}
+
+func TestMultiline_blocks(t *testing.T) {
+	// a match across empty rows, moved over where the first block ends, with
+	// rows before it that the block could end at too early
+	opts := DefaultOptions()
+	opts.Multiline = true
+	for _, algo := range algorithms {
+		opts.Algorithm = algo
+		m, err := CompileWithOptions("b\n\n\na", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for rows := multilineChunk/2 - 16; rows < multilineChunk/2+16; rows++ {
+			input := strings.Repeat("x\n", rows) + "b\n\n\nab\n" + strings.Repeat("x\n", 16)
+			got, err := m.FindReader(strings.NewReader(input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := Match{Row: rows + 1, Col: 0, Offset: int64(2 * rows), Len: 5, EndRow: rows + 4, EndCol: 1}
+			if len(got) != 1 || got[0] != want {
+				t.Fatalf("%v, %d rows before: got %+v, want %+v", algo, rows, got, want)
+			}
+		}
+	}
+}
+
+func TestMultiline_stream(t *testing.T) {
+	// the input is searched as it is read, so that stopping at the first
+	// match never gets as far as the error
+	opts := DefaultOptions()
+	opts.Multiline = true
+	m, err := CompileWithOptions("b\na", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := io.MultiReader(strings.NewReader(strings.Repeat("ab\n", multilineChunk)), iotest.ErrReader(errors.New("read failed")))
+	var got []Match
+	err = m.FindFunc(r, func(match Match) bool {
+		got = append(got, match)
+		return false
+	})
+	if err != nil || len(got) != 1 || got[0].String() != "1:1" {
+		t.Errorf("got %v, %v, want [1:1], nil", got, err)
+	}
+}
@@ -0,0 +1,73 @@
+package bench
+
+import (
+	"slices"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalization selects the Unicode normalization form patterns and lines
+// are put in before they are compared.
+type Normalization int
+
+const (
+	NoNormalization Normalization = iota // bytes are compared as they are
+	NFC                                  // canonical composition, e.g. e and U+0301 become é
+	NFD                                  // canonical decomposition, e.g. é becomes e and U+0301
+	NFKC                                 // compatibility composition, e.g. ﬁ becomes fi and ２ becomes 2
+	NFKD                                 // compatibility decomposition
+)
+
+var normForms = []norm.Form{
+	NFC:  norm.NFC,
+	NFD:  norm.NFD,
+	NFKC: norm.NFKC,
+	NFKD: norm.NFKD,
+}
+
+// normalize appends line, put in form f, to dst, and appends to offs the
+// offset in line of each byte appended to dst, followed by len(line). The
+// bytes of a segment that is normalized as a whole, such as a letter and the
+// marks that combine with it, all get the offset the segment starts at. When
+// line is already in form f, it is returned itself with nil offsets.
+func normalize(dst []byte, offs []int, f norm.Form, line []byte) ([]byte, []int) {
+	if f.IsNormal(line) {
+		return line, nil
+	}
+
+	var it norm.Iter
+	it.Init(f, line)
+	for !it.Done() {
+		start := it.Pos()
+		seg := it.Next()
+		for range seg {
+			offs = append(offs, start)
+		}
+		dst = append(dst, seg...)
+	}
+
+	return dst, append(offs, len(line))
+}
+
+// endOffset returns the offset in the original line of the end of a match
+// that ends before byte end of the searched text, which is the end of the
+// segment or rune that byte end is in, when it is not the first of it.
+func endOffset(offs []int, end int) int {
+	for end > 0 && end < len(offs)-1 && offs[end] == offs[end-1] {
+		end++
+	}
+
+	return offs[end]
+}
+
+// textOffset is the inverse of the offsets: it returns the offset in the
+// searched text of the first byte that comes from byte off of the original
+// line or after it.
+func textOffset(offs []int, off int) int {
+	if offs == nil {
+		return off
+	}
+
+	i, _ := slices.BinarySearch(offs, off)
+	return i
+}
@@ -0,0 +1,118 @@
+package bench
+
+import "testing"
+
+func TestFindWithOptions_normalize(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		form Normalization
+		want string
+	}{
+		// line 3 has café composed and decomposed, and line 5 한 as a
+		// syllable and as jamo
+		{"café", NoNormalization, "3:0"},
+		{"café", NFC, "3:0,3:10"},
+		{"café", NFD, "3:0,3:10"},
+		{"café", NFC, "3:0,3:10"},
+		{"café", NoNormalization, "3:10"},
+		{"한", NFC, "5:0,5:14"},
+		{"한", NFD, "5:0,5:14"},
+		{"한", NoNormalization, "5:0"},
+
+		// e is part of é, which NFC composes
+		{"e", NFC, "1:63,1:77,3:24,4:15,4:26,4:34,6:25"},
+		{"e", NFD, "1:63,1:77,3:3,3:13,3:21,3:24,4:15,4:26,4:34,6:25"},
+	} {
+		opts := DefaultOptions()
+		opts.Normalize = tc.form
+
+		got, err := FindWithOptions(pathEmoji, tc.s, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("%+q, form %d => %q, want %q", tc.s, tc.form, got, tc.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for _, tc := range []struct {
+		form     Normalization
+		fold     Fold
+		columns  ColumnUnit
+		s, line  string
+		col, len int
+	}{
+		// matches are widened to whole letters with their accents
+		{NFD, NoFold, ByteColumns, "e", "xé", 1, 2},
+		{NFD, NoFold, ByteColumns, "ä", "xä", 1, 2},
+		{NFC, NoFold, ByteColumns, "ä", "xä", 1, 3},
+
+		{NFKC, NoFold, ByteColumns, "file", "a ﬁle", 2, 5},
+		{NFKC, NoFold, ByteColumns, "2024", "year ２０２４", 5, 12},
+		{NFKD, NoFold, RuneColumns, "2024", "year ２０２４", 5, 4},
+		{NFC, NoFold, RuneColumns, "é", "café", 3, 2},
+
+		// folding is done after normalization
+		{NFC, FoldUnicode, ByteColumns, "CAFÉ", "café", 0, 6},
+		{NFC, FoldASCII, ByteColumns, "CAFé", "café", 0, 6},
+	} {
+		opts := DefaultOptions()
+		opts.Normalize = tc.form
+		opts.Fold = tc.fold
+		opts.Columns = tc.columns
+
+		for _, algo := range algorithms {
+			opts.Algorithm = algo
+			m, err := CompileWithOptions(tc.s, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := m.FindBytes([]byte(tc.line))
+			if len(got) != 1 || got[0].Col != tc.col || got[0].Len != tc.len {
+				t.Errorf("%v, form %d: %+q in %+q => %+v, want col %d len %d", algo, tc.form, tc.s, tc.line, got, tc.col, tc.len)
+			}
+		}
+	}
+}
+
+func TestNormalize_regexp(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Normalize = NFC
+	opts.Regexp = true
+	m, err := CompileWithOptions("caf[éè]", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err := m.FindFile(pathEmoji)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Format(matches); got != "3:0:5,3:10:6" {
+		t.Errorf("got %q, want %q", got, "3:0:5,3:10:6")
+	}
+}
+
+func Test_normalize(t *testing.T) {
+	line := []byte("éx")
+	if normed, offs := normalize(nil, nil, normForms[NFD], line); string(normed) != string(line) || offs != nil {
+		t.Errorf("a normal line => %+q %v, want it as it is", normed, offs)
+	}
+
+	normed, offs := normalize(nil, nil, normForms[NFC], line)
+	if string(normed) != "éx" {
+		t.Errorf("normalized %+q, want %+q", normed, "éx")
+	}
+	if expected := []int{0, 0, 3, 4}; !equalInts(offs, expected) {
+		t.Errorf("offsets %v, want %v", offs, expected)
+	}
+
+	for _, tc := range []struct{ end, want int }{{0, 0}, {1, 3}, {2, 3}, {3, 4}} {
+		if got := endOffset(offs, tc.end); got != tc.want {
+			t.Errorf("endOffset(%d) => %d, want %d", tc.end, got, tc.want)
+		}
+	}
+}
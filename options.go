@@ -0,0 +1,222 @@
+package bench
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Algorithm selects the string search backend.
+type Algorithm int
+
+const (
+	KMP         Algorithm = iota // Knuth-Morris-Pratt, the default
+	Horspool                     // Boyer-Moore-Horspool
+	BoyerMoore                   // Boyer-Moore with the good-suffix and Galil rules
+	TwoWay                       // Crochemore-Perrin Two-Way, with constant extra memory
+	AhoCorasick                  // Aho-Corasick, for any number of words in one pass
+	RabinKarp                    // Rabin-Karp, for any number of words of the same length
+	Auto                         // one of the above, picked by looking at the words
+	Bitap                        // Wu-Manber Bitap, for words of up to 64 bytes, with MaxErrors
+	Levenshtein                  // Sellers with Ukkonen's cut-off, for words of any length, with MaxErrors
+	PikeVM                       // NFA simulation, for glob patterns
+	RE2                          // the regexp package, for regular expressions
+)
+
+var algorithmNames = []string{
+	KMP:         "KMP",
+	Horspool:    "Horspool",
+	BoyerMoore:  "BoyerMoore",
+	TwoWay:      "TwoWay",
+	AhoCorasick: "AhoCorasick",
+	RabinKarp:   "RabinKarp",
+	Auto:        "Auto",
+	Bitap:       "Bitap",
+	Levenshtein: "Levenshtein",
+	PikeVM:      "PikeVM",
+	RE2:         "RE2",
+}
+
+func (a Algorithm) String() string {
+	if a < 0 || int(a) >= len(algorithmNames) {
+		return fmt.Sprintf("Algorithm(%d)", int(a))
+	}
+
+	return algorithmNames[a]
+}
+
+// multi reports whether the backend can search for more than one word at once.
+func (a Algorithm) multi() bool {
+	return a == AhoCorasick || a == RabinKarp
+}
+
+// Leftmost decides, when Overlap is off, which of several matches starting at
+// the same position is reported. It only matters when searching for more than
+// one pattern at once.
+type Leftmost int
+
+const (
+	LeftmostFirst   Leftmost = iota // the pattern given first wins
+	LeftmostLongest                 // the longest pattern wins
+)
+
+// Fold selects how letters that differ only in case are matched.
+type Fold int
+
+const (
+	NoFold      Fold = iota // bytes must be equal
+	FoldASCII               // A-Z and a-z are equal; other bytes must be equal
+	FoldUnicode             // runes that are equal under Unicode simple case folding are equal
+)
+
+// Options controls how a search is carried out and how its results are
+// reported. Start from DefaultOptions, which gives the same results as Find,
+// and change only the fields you need. The zero Options is not valid: its
+// empty Sep is rejected, since the results could not be told apart.
+type Options struct {
+	Overlap    bool   // also report matches that share bytes with an earlier match, rather than resuming after each one
+	RowBase    int    // row number of the first line
+	ColBase    int    // column of the first byte in a line
+	Sep        string // separator between row:col pairs in formatted results; must not be empty
+	MaxMatches int    // stop after this many matches; 0 means no limit
+
+	Algorithm Algorithm // search backend; results are the same whichever is used
+	Leftmost  Leftmost  // preferred match among those at the same position, without Overlap
+
+	// MaxErrors allows matches that differ from the pattern by up to this
+	// many substituted, inserted or deleted bytes. For every position where
+	// such a match ends, the closest one is reported, and formatted results
+	// become row:col:len:dist.
+	MaxErrors int
+
+	// Glob reads the pattern as a glob: ? is any byte, * any run of bytes,
+	// [a-z] and [!a-z] are classes, x{n,m} repeats the item x and \x is the
+	// byte x itself. For every position where a match starts, the shortest
+	// one is reported, and formatted results become row:col:len.
+	Glob bool
+
+	// Regexp reads the pattern as a regular expression, in the syntax of the
+	// regexp package, which is matched against one line at a time. Empty
+	// matches are not reported, and formatted results become row:col:len.
+	// With Overlap, the search is resumed at the next rune after the start of
+	// each match, so that every position a match starts at is reported;
+	// without it, the results are those of FindAllIndex, and Leftmost picks
+	// between leftmost-first and leftmost-longest matching.
+	Regexp bool
+
+	// Fold matches letters regardless of case. Columns and lengths are still
+	// those of the matches in the original line, even where folding changes
+	// the length of a rune. With Regexp, both modes use the regexp package's
+	// (?i) flag, which folds Unicode.
+	Fold Fold
+
+	// Normalize puts the pattern and each line in a Unicode normalization
+	// form before they are compared, so that, with NFC or NFD, é matches e
+	// followed by a combining acute accent. Columns and lengths are those of
+	// the matches in the original line; a match that starts or ends inside a
+	// run of runes that is normalized as a whole, such as a letter and its
+	// accents, is widened to all of it. Regular expressions are used as
+	// written. Folding, if any, is done after normalization.
+	Normalize Normalization
+
+	// Columns selects the unit of Match.Col and Match.Len: bytes, runes,
+	// UTF-16 code units or grapheme clusters. Each byte that is not part of
+	// valid UTF-8 counts as one rune, one UTF-16 code unit and one grapheme
+	// cluster, as it would once replaced by U+FFFD, and Match.Len is the
+	// column a match ends at less the one it starts at; in grapheme clusters,
+	// a match that starts or ends inside a cluster is widened to all of it.
+	// Match.Offset is always in bytes.
+	Columns ColumnUnit
+
+	// WholeGraphemes leaves out matches that start or end inside a grapheme
+	// cluster, such as half of a flag or of an emoji joined with ZWJ, or a
+	// letter without the accent that combines with it.
+	WholeGraphemes bool
+
+	// WholeWords only reports matches that are whole words: neither the
+	// character before a match nor the one after it may be part of a word.
+	// Which characters those are is chosen by the WordBoundary. They are
+	// looked at in the line as it is searched, after Normalize and Fold, so
+	// that e is not a whole word in e followed by a combining accent. With
+	// ASCIIWords and CustomWords, the line is looked at a byte at a time, so
+	// that any byte of a non-ASCII rune is not part of a word unless
+	// WordBytes says so.
+	WholeWords WordBoundary
+	WordBytes  string // the bytes words are made of, with CustomWords
+
+	// Multiline searches the input as a whole rather than a line at a time,
+	// so that a match may take in newlines, as a phrase wrapped across lines
+	// does. The input is still read a block of lines at a time, unless
+	// matches can be of any length, as with Regexp or a * in a Glob, when all
+	// of it is read before it is searched. Match.EndRow and Match.EndCol tell
+	// where each match ends, and formatted results become
+	// row:col-endrow:endcol, followed by :dist when matching with errors or
+	// :pattern with several patterns. With Regexp, ^ and $ match at the start
+	// and end of each line, and . only matches a newline with the (?s) flag.
+	Multiline bool
+
+	// LineEndings selects what ends a line, and so how rows are counted.
+	// Line endings are not part of the lines they end, so they are only
+	// searched with Multiline, and columns count from the start of the line
+	// after them. With the default, AutoEndings, lines end at \n or \r\n, as
+	// with bufio.ScanLines, unless the input has no \n at all, as in old Mac
+	// files, in which case they end at \r; that takes reading all of it before
+	// its first line is searched, which CREndings does not.
+	LineEndings LineEnding
+
+	// Best only reports this many of the closest matches, ordered by edit
+	// distance and then by position. 0 reports every match, in order. With
+	// MaxMatches set as well, every match is still ranked, and MaxMatches only
+	// cuts how many of the closest are reported.
+	Best int
+}
+
+// DefaultOptions returns the options Find uses: overlapping matches, rows
+// counted from 1, byte columns counted from 0, separated by ",", no limit.
+func DefaultOptions() Options {
+	return Options{
+		Overlap: true,
+		RowBase: 1,
+		Sep:     ",",
+	}
+}
+
+func (o Options) validate() error {
+	if o.Sep == "" {
+		return errors.New("Sep cannot be empty; start from DefaultOptions")
+	}
+	if o.MaxMatches < 0 {
+		return errors.New("MaxMatches cannot be negative")
+	}
+	if o.Algorithm < 0 || int(o.Algorithm) >= len(algorithmNames) {
+		return fmt.Errorf("unknown algorithm %v", o.Algorithm)
+	}
+	if o.MaxErrors < 0 {
+		return errors.New("MaxErrors cannot be negative")
+	}
+	if o.Best < 0 {
+		return errors.New("Best cannot be negative")
+	}
+	if o.Fold < NoFold || o.Fold > FoldUnicode {
+		return fmt.Errorf("unknown fold mode %d", int(o.Fold))
+	}
+	if o.Normalize < NoNormalization || o.Normalize > NFKD {
+		return fmt.Errorf("unknown normalization form %d", int(o.Normalize))
+	}
+	if o.Columns < ByteColumns || o.Columns > GraphemeColumns {
+		return fmt.Errorf("unknown column unit %d", int(o.Columns))
+	}
+	if o.WholeWords < NoWordBoundary || o.WholeWords > CustomWords {
+		return fmt.Errorf("unknown word boundary %d", int(o.WholeWords))
+	}
+	if o.WholeWords == CustomWords && o.WordBytes == "" {
+		return errors.New("WordBytes cannot be empty with CustomWords")
+	}
+	if o.LineEndings < AutoEndings || o.LineEndings > AnyEndings {
+		return fmt.Errorf("unknown line endings %d", int(o.LineEndings))
+	}
+	if o.Leftmost != LeftmostFirst && o.Leftmost != LeftmostLongest {
+		return fmt.Errorf("unknown leftmost mode %d", int(o.Leftmost))
+	}
+
+	return nil
+}
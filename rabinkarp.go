@@ -0,0 +1,104 @@
+package bench
+
+import "bytes"
+
+// primeRK is the base of the rolling hash, as in the standard library's bytes
+// package.
+const primeRK = 16777619
+
+// Rabin-Karp with a set of words that all have the same length. A rolling
+// hash of the last len(word) bytes of the line is looked up in a small hash
+// table, which takes far less memory per word than an Aho-Corasick automaton.
+// Every hash hit is compared byte for byte, so collisions are never reported.
+// via: http://en.wikipedia.org/wiki/Rabin–Karp_algorithm
+type rabinKarp struct {
+	words [][]byte
+	n     int    // length of every word
+	pow   uint32 // primeRK^n, to roll the oldest byte out of the hash
+
+	shift  uint     // bucket for hash h is h*fibHash >> shift
+	bucket []int32  // first word in each bucket, -1 if none
+	next   []int32  // next word in the same bucket, -1 at the end
+	hash   []uint32 // hash of each word
+}
+
+// fibHash spreads the hash over the bucket table, see slot.
+const fibHash = 0x9E3779B1
+
+// rkSearch stores every occurrence of any word in line into result, ordered
+// by where the occurrences start.
+func rkSearch(rk *rabinKarp, line []byte, result []hit) []hit {
+	n := rk.n
+
+	// "empty" the initial result by setting its length to zero
+	result = result[0:0]
+
+	if len(line) < n {
+		return result
+	}
+
+	h := rkHash(line[:n])
+	for m := 0; ; m++ {
+		for p := rk.bucket[rk.slot(h)]; p >= 0; p = rk.next[p] {
+			if rk.hash[p] == h && bytes.Equal(rk.words[p], line[m:m+n]) {
+				// got a match, and not just a collision
+				result = append(result, hit{start: m, end: m + n, pattern: int(p)})
+			}
+		}
+
+		if m+n == len(line) {
+			break
+		}
+		h = h*primeRK + uint32(line[m+n]) - rk.pow*uint32(line[m])
+	}
+
+	return result
+}
+
+// builds the Rabin-Karp hash table for words, which must all be of the same
+// length.
+func rkBuild(words [][]byte) *rabinKarp {
+	rk := &rabinKarp{words: words, n: len(words[0]), pow: 1, shift: 32}
+
+	for i := 0; i < rk.n; i++ {
+		rk.pow *= primeRK
+	}
+
+	// at least twice as many buckets as words, so that chains stay short
+	size := 1
+	for size < 2*len(words) {
+		size *= 2
+		rk.shift--
+	}
+	rk.bucket = make([]int32, size)
+	for i := range rk.bucket {
+		rk.bucket[i] = -1
+	}
+
+	rk.next = make([]int32, len(words))
+	rk.hash = make([]uint32, len(words))
+	for p, word := range words {
+		h := rkHash(word)
+		slot := rk.slot(h)
+		rk.hash[p] = h
+		rk.next[p] = rk.bucket[slot]
+		rk.bucket[slot] = int32(p)
+	}
+
+	return rk
+}
+
+// slot returns the bucket for hash h, taken from the top bits of h*fibHash.
+func (rk *rabinKarp) slot(h uint32) uint32 {
+	return h * fibHash >> rk.shift
+}
+
+// rkHash returns the Rabin-Karp hash of b.
+func rkHash(b []byte) uint32 {
+	var h uint32
+	for _, c := range b {
+		h = h*primeRK + uint32(c)
+	}
+
+	return h
+}
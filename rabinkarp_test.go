@@ -0,0 +1,156 @@
+package bench
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestRabinKarp_random(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	opts := DefaultOptions()
+	opts.Algorithm = RabinKarp
+
+	for _, alphabet := range []string{"ab", "abc"} {
+		for n := 0; n < 200; n++ {
+			size := 1 + rnd.Intn(4)
+			words := make([]string, 1+rnd.Intn(10))
+			for i := range words {
+				word := make([]byte, size)
+				for j := range word {
+					word[j] = alphabet[rnd.Intn(len(alphabet))]
+				}
+				words[i] = string(word)
+			}
+			line := make([]byte, rnd.Intn(32))
+			for i := range line {
+				line[i] = alphabet[rnd.Intn(len(alphabet))]
+			}
+
+			m, err := CompileAllWithOptions(words, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := naiveSearchAll(words, string(line), true, LeftmostFirst)
+			got := m.FindBytes(line)
+			if len(got) != len(expected) {
+				t.Fatalf("%q in %q => %v, want %v", words, line, got, expected)
+			}
+			for i, match := range got {
+				h := expected[i]
+				if match.Col != h.start || match.Pattern != h.pattern {
+					t.Fatalf("%q in %q => %v, want %v", words, line, got, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestRabinKarp_collision(t *testing.T) {
+	// force every word into one bucket with the same hash, so that only the
+	// byte comparison can tell them apart
+	rk := rkBuild([][]byte{[]byte("ab"), []byte("ba")})
+	rk.hash[1] = rk.hash[0]
+	rk.bucket = []int32{1}
+	rk.next = []int32{-1, 0}
+	rk.shift = 32
+
+	got := rkSearch(rk, []byte("xab"), nil)
+	if len(got) != 1 || got[0] != (hit{start: 1, end: 3}) {
+		t.Errorf("rkSearch => %v, want [{1 3 0}]", got)
+	}
+}
+
+func TestCompileAll_rabinKarpLengths(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Algorithm = RabinKarp
+	if _, err := CompileAllWithOptions([]string{"aa", "aaa"}, opts); err == nil {
+		t.Error("some kind of error should be returned")
+	}
+}
+
+func TestCompileAll_format(t *testing.T) {
+	for _, tc := range []struct {
+		patterns []string
+		algo     Algorithm
+		want     string
+	}{
+		// patterns of one length leave it out, as a single word does
+		{[]string{"aa", "ee"}, RabinKarp, "1:0:0,1:8:1,1:10:0,1:18:1,6:0:0,6:1:0,6:12:1,6:13:1"},
+		{[]string{"aa", "ee"}, AhoCorasick, "1:0:0,1:8:1,1:10:0,1:18:1,6:0:0,6:1:0,6:12:1,6:13:1"},
+		{[]string{"aa", "aab"}, AhoCorasick, "1:0:2:0,1:0:3:1,1:10:2:0,1:10:3:1,6:0:2:0,6:1:2:0,6:1:3:1"},
+	} {
+		opts := DefaultOptions()
+		opts.Algorithm = tc.algo
+		m, err := CompileAllWithOptions(tc.patterns, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		matches, err := m.FindFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Format(matches); got != tc.want {
+			t.Errorf("%v %q => %q, want %q", tc.algo, tc.patterns, got, tc.want)
+		}
+	}
+}
+
+// equalLengthPatterns returns every two byte word over a-j.
+func equalLengthPatterns() []string {
+	var patterns []string
+	for a := 'a'; a <= 'j'; a++ {
+		for b := 'a'; b <= 'j'; b++ {
+			patterns = append(patterns, string([]rune{a, b}))
+		}
+	}
+
+	return patterns
+}
+
+func BenchmarkFindAll_equalLength(b *testing.B) {
+	data, err := os.ReadFile(pathLarge)
+	if err != nil {
+		b.Fatal(err)
+	}
+	patterns := equalLengthPatterns()
+
+	for _, algo := range []Algorithm{RabinKarp, AhoCorasick} {
+		opts := DefaultOptions()
+		opts.Algorithm = algo
+		m, err := CompileAllWithOptions(patterns, opts)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(algo.String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m.FindReader(bytes.NewReader(data))
+			}
+		})
+	}
+
+	// a single pass of KMP, and one per pattern
+	m, _ := Compile(word)
+	b.Run("KMP/one", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			m.FindReader(bytes.NewReader(data))
+		}
+	})
+
+	var ms []*Matcher
+	for _, p := range patterns {
+		m, _ := Compile(p)
+		ms = append(ms, m)
+	}
+	b.Run("KMP/each", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, m := range ms {
+				m.FindReader(bytes.NewReader(data))
+			}
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package bench
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"unicode/utf8"
+)
+
+// re2 is a regular expression search, for Options.Regexp, run by the standard
+// library's RE2 engine.
+type re2 struct {
+	re      *regexp.Regexp
+	overlap bool
+
+	// ctx is re preceded by one more byte, used to resume a search part
+	// way through a line with the byte before in view; it is nil when re
+	// has no assertions that look at the byte before them
+	ctx *regexp.Regexp
+
+	// every match contains lit, which is found with kmpSearch first to skip
+	// the lines that cannot match
+	lit []byte
+	T   []int
+}
+
+func re2Build(pattern string, overlap, longest bool) (*re2, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if longest {
+		re.Longest()
+	}
+
+	tree, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &re2{re: re, overlap: overlap}
+	if overlap && re2LooksBehind(tree) {
+		if r.ctx, err = regexp.Compile(`(?s:.)(` + pattern + `)`); err != nil {
+			return nil, err
+		}
+		if longest {
+			r.ctx.Longest()
+		}
+	}
+
+	if lit := re2Required(tree.Simplify()); len(lit) > 1 {
+		r.lit = lit
+		r.T = kmpBuildTable(string(lit))
+	}
+
+	return r, nil
+}
+
+// re2Search stores every match of the regular expression in line into
+// buf.hits, leaving out empty ones. Without overlap these are the matches
+// FindAllIndex reports. With overlap, the search is resumed one rune after
+// the start of each match, so that a match is reported at every position one
+// starts at.
+func re2Search(r *re2, line []byte, buf *searchBuffer) []hit {
+	// "empty" the initial result by setting its length to zero
+	buf.hits = buf.hits[0:0]
+
+	if len(r.lit) > 0 {
+		buf.cols = kmpSearch(r.T, r.lit, line, buf.cols)
+		if len(buf.cols) == 0 {
+			return buf.hits
+		}
+	}
+
+	if !r.overlap {
+		for _, loc := range r.re.FindAllIndex(line, -1) {
+			if loc[1] > loc[0] {
+				buf.hits = append(buf.hits, hit{start: loc[0], end: loc[1]})
+			}
+		}
+		return buf.hits
+	}
+
+	for p := 0; p < len(line); {
+		var start, end int
+		if p > 0 && r.ctx != nil {
+			// ^ and \b would take a resumed search to start the text, so
+			// keep the byte before it in view
+			sub := r.ctx.FindSubmatchIndex(line[p-1:])
+			if sub == nil {
+				break
+			}
+			start, end = p-1+sub[2], p-1+sub[3]
+		} else {
+			loc := r.re.FindIndex(line[p:])
+			if loc == nil {
+				break
+			}
+			start, end = p+loc[0], p+loc[1]
+		}
+
+		if end > start {
+			buf.hits = append(buf.hits, hit{start: start, end: end})
+		}
+
+		// resuming inside a rune would have its other bytes read as U+FFFD
+		_, size := utf8.DecodeRune(line[start:])
+		p = start + max(size, 1)
+	}
+
+	return buf.hits
+}
+
+// re2LooksBehind reports whether the expression has an assertion about the
+// byte before where it is tried.
+func re2LooksBehind(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpBeginLine, syntax.OpBeginText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return true
+	}
+	for _, sub := range re.Sub {
+		if re2LooksBehind(sub) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// re2Required returns the longest run of bytes that every match of the
+// expression contains, if one is easy to see.
+func re2Required(re *syntax.Regexp) []byte {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return nil
+		}
+		lit := make([]byte, 0, len(re.Rune))
+		for _, r := range re.Rune {
+			lit = utf8.AppendRune(lit, r)
+		}
+		return lit
+	case syntax.OpCapture, syntax.OpPlus:
+		return re2Required(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return re2Required(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		var best []byte
+		for _, sub := range re.Sub {
+			if lit := re2Required(sub); len(lit) > len(best) {
+				best = lit
+			}
+		}
+		return best
+	}
+
+	return nil
+}
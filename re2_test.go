@@ -0,0 +1,135 @@
+package bench
+
+import (
+	"regexp/syntax"
+	"testing"
+)
+
+func TestFindWithOptions_regexp(t *testing.T) {
+	for _, tc := range []struct {
+		s       string
+		overlap bool
+		want    string
+	}{
+		{"a+b", true, "1:0:3,1:1:2,1:10:3,1:11:2,6:0:4,6:1:3,6:2:2"},
+		{"a+b", false, "1:0:3,1:10:3,6:0:4"},
+		{"^[a-e]+", true, "1:0:20,6:0:15"},
+		{"(dd|ee)$", false, "1:18:2"},
+		{"aa", true, "1:0:2,1:10:2,6:0:2,6:1:2"},
+	} {
+		opts := DefaultOptions()
+		opts.Regexp = true
+		opts.Overlap = tc.overlap
+
+		got, err := FindWithOptions(path, tc.s, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("FindWithOptions(%q, %q) overlap %v => %q, want %q", path, tc.s, tc.overlap, got, tc.want)
+		}
+	}
+}
+
+func TestRegexp_assertions(t *testing.T) {
+	for _, tc := range []struct {
+		s, line, want string
+	}{
+		// resuming the search part way through must not make ^ or \b match
+		{"^a", "aaa", "1:0:1"},
+		{`\ba+`, "aa baa aa", "1:0:2,1:7:2"},
+		{`\Ba`, "aaa", "1:1:1,1:2:1"},
+		{"(?m)^x", "x\nxx", "1:0:1,2:0:1"},
+	} {
+		opts := DefaultOptions()
+		opts.Regexp = true
+		m, err := CompileWithOptions(tc.s, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Format(m.FindBytes([]byte(tc.line))); got != tc.want {
+			t.Errorf("%q in %q => %q, want %q", tc.s, tc.line, got, tc.want)
+		}
+	}
+}
+
+func TestRegexp_runes(t *testing.T) {
+	// a match never starts inside a rune
+	for _, tc := range []struct {
+		s, line string
+		overlap bool
+		want    string
+	}{
+		{".", "😀é", true, "1:0:4,1:4:2"},
+		{"[^a]", "é😀a", true, "1:0:2,1:2:4"},
+		{"[^x]+", "éa😀", true, "1:0:7,1:2:5,1:3:4"},
+		{`\PL`, "é1😀", true, "1:2:1,1:3:4"},
+		{".", "😀é", false, "1:0:4,1:4:2"},
+		{"[^a]+", "a東京a", false, "1:1:6"},
+	} {
+		opts := DefaultOptions()
+		opts.Regexp = true
+		opts.Overlap = tc.overlap
+		m, err := CompileWithOptions(tc.s, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Format(m.FindBytes([]byte(tc.line))); got != tc.want {
+			t.Errorf("%q in %q, overlap %v => %q, want %q", tc.s, tc.line, tc.overlap, got, tc.want)
+		}
+	}
+}
+
+func TestRegexp_leftmost(t *testing.T) {
+	for _, tc := range []struct {
+		leftmost Leftmost
+		want     string
+	}{
+		{LeftmostFirst, "1:0:1,1:2:1"},
+		{LeftmostLongest, "1:0:2,1:2:1"},
+	} {
+		opts := DefaultOptions()
+		opts.Regexp = true
+		opts.Overlap = false
+		opts.Leftmost = tc.leftmost
+		m, err := CompileWithOptions("a|ab", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Format(m.FindBytes([]byte("abac"))); got != tc.want {
+			t.Errorf("leftmost %v => %q, want %q", tc.leftmost, got, tc.want)
+		}
+	}
+}
+
+func TestFindWithOptions_regexpBad(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Regexp = true
+	if _, err := FindWithOptions(path, "a(", opts); err == nil {
+		t.Error("some kind of error should be returned")
+	}
+
+	opts.Glob = true
+	if _, err := FindWithOptions(path, "a", opts); err == nil {
+		t.Error("some kind of error should be returned")
+	}
+}
+
+func Test_re2Required(t *testing.T) {
+	for _, tc := range []struct{ re, want string }{
+		{"foo[0-9]+barbaz", "barbaz"},
+		{"(error|warning): x", ": x"},
+		{"(?:timeout){2,}", "timeout"},
+		{"(?i)error", ""},
+		{"a|b", ""},
+		{"é+", "é"},
+	} {
+		tree, err := syntax.Parse(tc.re, syntax.Perl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(re2Required(tree.Simplify())); got != tc.want {
+			t.Errorf("re2Required(%q) => %q, want %q", tc.re, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package bench
+
+import (
+	"bytes"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// algorithms lists every single-word backend, each of which must give the
+// same results as KMP.
+var algorithms = []Algorithm{KMP, Horspool, BoyerMoore, TwoWay, AhoCorasick, RabinKarp, Auto, Bitap, Levenshtein}
+
+// naiveSearch is the obviously correct reference the backends are checked
+// against.
+func naiveSearch(word, line []byte) []int {
+	var result []int
+	for m := 0; m+len(word) <= len(line); m++ {
+		if bytes.Equal(word, line[m:m+len(word)]) {
+			result = append(result, m)
+		}
+	}
+
+	return result
+}
+
+// naiveSearchNonOverlapping is naiveSearch resuming after each match.
+func naiveSearchNonOverlapping(word, line []byte) []int {
+	var result []int
+	for m := 0; m+len(word) <= len(line); m++ {
+		if bytes.Equal(word, line[m:m+len(word)]) {
+			result = append(result, m)
+			m += len(word) - 1
+		}
+	}
+
+	return result
+}
+
+// nonOverlapping leaves out of the formatted row:col results of a word n bytes
+// long those that overlap an earlier one in the same row.
+func nonOverlapping(results string, n int) string {
+	var kept []string
+	row, next := "", 0
+	for _, rc := range strings.Split(results, ",") {
+		r, c, _ := strings.Cut(rc, ":")
+		col, err := strconv.Atoi(c)
+		if err != nil {
+			panic(err)
+		}
+		if r == row && col < next {
+			continue
+		}
+		kept = append(kept, rc)
+		row, next = r, col+n
+	}
+
+	return strings.Join(kept, ",")
+}
+
+// searchCols runs m's backend over line and returns where the matches start.
+func searchCols(m *Matcher, line []byte) []int {
+	var cols []int
+	for _, h := range m.search(line, &searchBuffer{}) {
+		cols = append(cols, h.start)
+	}
+
+	return cols
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestAlgorithms_data(t *testing.T) {
+	for _, algo := range algorithms {
+		opts := DefaultOptions()
+		opts.Algorithm = algo
+
+		for _, tc := range []struct{ path, want string }{{path, want}, {pathLarge, wantLarge}} {
+			got, err := FindWithOptions(tc.path, word, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("%v: FindWithOptions(%q, %q) => %q, want %q", algo, tc.path, word, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestAlgorithms_dataNoOverlap(t *testing.T) {
+	for _, algo := range algorithms {
+		opts := DefaultOptions()
+		opts.Algorithm = algo
+		opts.Overlap = false
+
+		for _, tc := range []struct{ path, want string }{{path, want}, {pathLarge, wantLarge}} {
+			expected := nonOverlapping(tc.want, len(word))
+			got, err := FindWithOptions(tc.path, word, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != expected {
+				t.Errorf("%v: FindWithOptions(%q, %q) => %q, want %q", algo, tc.path, word, got, expected)
+			}
+		}
+	}
+}
+
+func TestAlgorithms_random(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	// small alphabets give lots of periodic words and overlapping matches
+	for _, alphabet := range []string{"a", "ab", "abc", "abcdefghij"} {
+		for n := 0; n < 200; n++ {
+			word := make([]byte, 1+rnd.Intn(8))
+			for i := range word {
+				word[i] = alphabet[rnd.Intn(len(alphabet))]
+			}
+			line := make([]byte, rnd.Intn(64))
+			for i := range line {
+				line[i] = alphabet[rnd.Intn(len(alphabet))]
+			}
+
+			expected := naiveSearch(word, line)
+			for _, algo := range algorithms {
+				opts := DefaultOptions()
+				opts.Algorithm = algo
+				m, err := CompileWithOptions(string(word), opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got := searchCols(m, line)
+				if !equalInts(got, expected) {
+					t.Errorf("%v: search(%q, %q) => %v, want %v", algo, word, line, got, expected)
+				}
+			}
+
+			expected = naiveSearchNonOverlapping(word, line)
+			for _, algo := range algorithms {
+				opts := DefaultOptions()
+				opts.Algorithm = algo
+				opts.Overlap = false
+				m, err := CompileWithOptions(string(word), opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var got []int
+				for _, match := range m.FindBytes(line) {
+					got = append(got, match.Col)
+				}
+				if !equalInts(got, expected) {
+					t.Errorf("%v, no overlap: search(%q, %q) => %v, want %v", algo, word, line, got, expected)
+				}
+			}
+		}
+	}
+}
+
+func TestAlgorithms_noOverlapWindows(t *testing.T) {
+	// the last match of the first window runs into the second one
+	line := []byte(strings.Repeat("a", 2*searchWindow+7))
+	expected := naiveSearchNonOverlapping([]byte("aaa"), line)
+	for _, algo := range algorithms {
+		opts := DefaultOptions()
+		opts.Algorithm = algo
+		opts.Overlap = false
+		m, err := CompileWithOptions("aaa", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []int
+		for _, match := range m.FindBytes(line) {
+			got = append(got, match.Col)
+		}
+		if !equalInts(got, expected) {
+			t.Errorf("%v: got %d matches, want %d", algo, len(got), len(expected))
+		}
+	}
+}
+
+func TestCompileWithOptions_badAlgorithm(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Algorithm = -1
+	if _, err := CompileWithOptions(word, opts); err == nil {
+		t.Error("some kind of error should be returned")
+	}
+}
+
+func Test_horspoolBuildTable(t *testing.T) {
+	W := "ABCDABD"
+	skip := horspoolBuildTable([]byte(W))
+
+	expected := map[byte]int{'A': 2, 'B': 1, 'C': 4, 'D': 3, 'Z': 7}
+
+	for b, v := range expected {
+		if skip[b] != v {
+			t.Errorf("horspoolBuildTable(%q)[%q] => %v, want %v", W, b, skip[b], v)
+		}
+	}
+}
+
+func Test_bmBuildGoodSuffixTable_ABCDABD(t *testing.T) {
+	W := "ABCDABD"
+	gs := bmBuildGoodSuffixTable([]byte(W))
+
+	expected := []int{7, 7, 7, 7, 7, 3, 1}
+
+	if len(expected) != len(gs) {
+		t.Errorf("bmBuildGoodSuffixTable(%q) => %v, want %v", W, gs, expected)
+	}
+
+	for i, v := range gs {
+		if v != expected[i] {
+			t.Errorf("bmBuildGoodSuffixTable(%q) => %v, want %v", W, gs, expected)
+			break
+		}
+	}
+}
+
+func Test_bmBuildGoodSuffixTable_GCAGAGAG(t *testing.T) {
+	W := "GCAGAGAG"
+	gs := bmBuildGoodSuffixTable([]byte(W))
+
+	expected := []int{7, 7, 7, 2, 7, 4, 7, 1}
+
+	if len(expected) != len(gs) {
+		t.Errorf("bmBuildGoodSuffixTable(%q) => %v, want %v", W, gs, expected)
+	}
+
+	for i, v := range gs {
+		if v != expected[i] {
+			t.Errorf("bmBuildGoodSuffixTable(%q) => %v, want %v", W, gs, expected)
+			break
+		}
+	}
+}
+
+func Test_bmBuildGoodSuffixTable_PARTICIPATE(t *testing.T) {
+	W := "PARTICIPATE IN PARACHUTE"
+	gs := bmBuildGoodSuffixTable([]byte(W))
+
+	expected := []int{24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 24, 13, 24, 1}
+
+	if len(expected) != len(gs) {
+		t.Errorf("bmBuildGoodSuffixTable(%q) => %v, want %v", W, gs, expected)
+	}
+
+	for i, v := range gs {
+		if v != expected[i] {
+			t.Errorf("bmBuildGoodSuffixTable(%q) => %v, want %v", W, gs, expected)
+			break
+		}
+	}
+}
+
+func Test_bmSuffixes_GCAGAGAG(t *testing.T) {
+	W := "GCAGAGAG"
+	suff := bmSuffixes([]byte(W))
+
+	expected := []int{1, 0, 0, 2, 0, 4, 0, 8}
+
+	for i, v := range suff {
+		if v != expected[i] {
+			t.Errorf("bmSuffixes(%q) => %v, want %v", W, suff, expected)
+			break
+		}
+	}
+}
+
+func Test_bmSearch_galil(t *testing.T) {
+	// on a run of the same byte every alignment matches, and Galil's rule
+	// makes each one after the first cost a single comparison
+	word := []byte("aaaaaaaa")
+	line := bytes.Repeat([]byte("a"), 1000)
+	gs := bmBuildGoodSuffixTable(word)
+
+	got := bmSearch(horspoolBuildTable(word), gs, word, line, nil)
+	if len(got) != len(line)-len(word)+1 {
+		t.Errorf("bmSearch found %d matches, want %d", len(got), len(line)-len(word)+1)
+	}
+}
+
+func Test_twoWayFactorize(t *testing.T) {
+	for _, tc := range []struct {
+		W        string
+		ell, per int
+	}{
+		{"a", -1, 1},
+		{"aaaa", -1, 1},
+		{"abaab", 1, 3},
+		{"GCAGAGAG", 1, 2},
+	} {
+		ell, per := twoWayFactorize([]byte(tc.W))
+		if ell != tc.ell || per != tc.per {
+			t.Errorf("twoWayFactorize(%q) => %d, %d, want %d, %d", tc.W, ell, per, tc.ell, tc.per)
+		}
+	}
+}
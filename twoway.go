@@ -0,0 +1,121 @@
+package bench
+
+// Two-Way algorithm (Crochemore-Perrin), modified to return all occurrences,
+// including overlapping ones, in the same way as kmpSearch. The word is split
+// at a critical position ell: the right part word[ell+1:] is matched left to
+// right, then the left part right to left. Unlike kmpBuildTable, it only needs
+// ell and the period of the word, so the extra memory is constant however long
+// the word is.
+// via: http://www-igm.univ-mlv.fr/~lecroq/string/node26.html
+func twoWaySearch(ell, per int, word, line []byte, result []int) []int {
+	n := len(word)
+
+	// "empty" the initial result by setting its length to zero
+	result = result[0:0]
+
+	if per+ell+1 <= n && string(word[:ell+1]) == string(word[per:per+ell+1]) {
+		// the word is periodic; after shifting by the period, its first
+		// n-per bytes are already known to match, so remember them
+		memory := -1
+		for j := 0; j <= len(line)-n; {
+			i := max(ell, memory) + 1
+			for i < n && word[i] == line[i+j] {
+				i++
+			}
+
+			if i >= n {
+				i = ell
+				for i > memory && word[i] == line[i+j] {
+					i--
+				}
+				if i <= memory {
+					// got a match
+					result = append(result, j)
+				}
+				j += per
+				memory = n - per - 1
+			} else {
+				j += i - ell
+				memory = -1
+			}
+		}
+	} else {
+		// otherwise any shift up to the larger half is safe
+		per = max(ell+1, n-ell-1) + 1
+		for j := 0; j <= len(line)-n; {
+			i := ell + 1
+			for i < n && word[i] == line[i+j] {
+				i++
+			}
+
+			if i >= n {
+				i = ell
+				for i >= 0 && word[i] == line[i+j] {
+					i--
+				}
+				if i < 0 {
+					// got a match
+					result = append(result, j)
+				}
+				j += per
+			} else {
+				j += i - ell
+			}
+		}
+	}
+
+	return result
+}
+
+// finds a critical factorization of word for Two-Way string search: the last
+// index ell of the left part, and the period of the right part, which is the
+// period of the whole word when that is periodic.
+func twoWayFactorize(word []byte) (ell, per int) {
+	i, p := twoWayMaxSuffix(word, false)
+	j, q := twoWayMaxSuffix(word, true)
+
+	if i > j {
+		return i, p
+	}
+
+	return j, q
+}
+
+// twoWayMaxSuffix returns the position just before the lexicographically
+// largest suffix of word and that suffix's period. With reversed set, the
+// byte order is reversed.
+func twoWayMaxSuffix(word []byte, reversed bool) (ms, p int) {
+	ms = -1
+	j := 0
+	k := 1
+	p = 1
+
+	for j+k < len(word) {
+		a := word[j+k]
+		b := word[ms+k]
+		if reversed {
+			a, b = b, a
+		}
+
+		switch {
+		case a < b:
+			j += k
+			k = 1
+			p = j - ms
+		case a == b:
+			if k != p {
+				k++
+			} else {
+				j += p
+				k = 1
+			}
+		default:
+			ms = j
+			j = ms + 1
+			k = 1
+			p = 1
+		}
+	}
+
+	return ms, p
+}
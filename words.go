@@ -0,0 +1,51 @@
+package bench
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// WordBoundary selects which characters make up words, for whole word
+// matching.
+type WordBoundary int
+
+const (
+	NoWordBoundary WordBoundary = iota // matches may start and end anywhere
+	ASCIIWords                         // words are made of A-Z, a-z, 0-9 and _, like \w
+	UnicodeWords                       // words are made of letters, digits, marks and _ in any script
+	CustomWords                        // words are made of the bytes in Options.WordBytes
+)
+
+// asciiWordBytes is \w.
+var asciiWordBytes = func() (s byteSet) {
+	s.addRange('0', '9')
+	s.addRange('A', 'Z')
+	s.addRange('a', 'z')
+	s.add('_')
+	return s
+}()
+
+// isWordRune reports whether r is part of a word for UnicodeWords.
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+// wholeWord reports whether line[lo:hi] is a whole word: neither the
+// character before it nor the one after it is part of a word.
+func (m *Matcher) wholeWord(line []byte, lo, hi int) bool {
+	if m.opts.WholeWords == UnicodeWords {
+		if lo > 0 {
+			if r, _ := utf8.DecodeLastRune(line[:lo]); isWordRune(r) {
+				return false
+			}
+		}
+		if hi < len(line) {
+			if r, _ := utf8.DecodeRune(line[hi:]); isWordRune(r) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return (lo == 0 || !m.wordBytes.has(line[lo-1])) && (hi == len(line) || !m.wordBytes.has(line[hi]))
+}
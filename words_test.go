@@ -0,0 +1,129 @@
+package bench
+
+import "testing"
+
+func TestFindWithOptions_wholeWords(t *testing.T) {
+	// every line of data.txt is a single word
+	opts := DefaultOptions()
+	opts.WholeWords = ASCIIWords
+	got, err := FindWithOptions(path, word, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Errorf("FindWithOptions(%q, %q) => %q, want %q", path, word, got, "")
+	}
+
+	got, err = FindWithOptions(path, "aabbccddeeaabbccddee", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "1:0" {
+		t.Errorf("FindWithOptions(%q, %q) => %q, want %q", path, "aabbccddeeaabbccddee", got, "1:0")
+	}
+}
+
+func TestWholeWords(t *testing.T) {
+	const line = "aa aaa aa_b aa-aa (aa) éaa aa"
+
+	for _, tc := range []struct {
+		boundary  WordBoundary
+		wordBytes string
+		want      string
+	}{
+		{NoWordBoundary, "", "1:0,1:3,1:4,1:7,1:12,1:15,1:19,1:25,1:28"},
+		{ASCIIWords, "", "1:0,1:12,1:15,1:19,1:25,1:28"},
+		{UnicodeWords, "", "1:0,1:12,1:15,1:19,1:28"},
+		{CustomWords, "a", "1:0,1:7,1:12,1:15,1:19,1:25,1:28"},
+		{CustomWords, "a-", "1:0,1:7,1:19,1:25,1:28"},
+	} {
+		opts := DefaultOptions()
+		opts.WholeWords = tc.boundary
+		opts.WordBytes = tc.wordBytes
+
+		for _, algo := range algorithms {
+			opts.Algorithm = algo
+			m, err := CompileWithOptions("aa", opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.Format(m.FindBytes([]byte(line))); got != tc.want {
+				t.Errorf("%v, boundary %d %q => %q, want %q", algo, tc.boundary, tc.wordBytes, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestWholeWords_noOverlap(t *testing.T) {
+	// a match left out does not hide the ones it overlaps
+	opts := DefaultOptions()
+	opts.Overlap = false
+	opts.WholeWords = ASCIIWords
+	m, err := CompileAllWithOptions([]string{"ab", "abc d"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Format(m.FindBytes([]byte("abc def ab"))); got != "1:8:2:0" {
+		t.Errorf("got %q, want %q", got, "1:8:2:0")
+	}
+
+	opts.Leftmost = LeftmostLongest
+	if m, err = CompileAllWithOptions([]string{"abc", "abc d"}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Format(m.FindBytes([]byte("abc de abc"))); got != "1:0:3:0,1:7:3:0" {
+		t.Errorf("got %q, want %q", got, "1:0:3:0,1:7:3:0")
+	}
+}
+
+func TestWholeWords_unicode(t *testing.T) {
+	for _, tc := range []struct{ s, line, want string }{
+		{"naïve", "naïve naïvely", "1:0"},
+		{"café", "cafés café.", "1:7"},
+		{"東京", "東京都 東京", "1:10"},
+		{"e", "é e", "1:3"},
+	} {
+		opts := DefaultOptions()
+		opts.WholeWords = UnicodeWords
+		opts.Normalize = NFD
+		m, err := CompileWithOptions(tc.s, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Format(m.FindBytes([]byte(tc.line))); got != tc.want {
+			t.Errorf("%q in %q => %q, want %q", tc.s, tc.line, got, tc.want)
+		}
+	}
+}
+
+func TestWholeWords_fold(t *testing.T) {
+	for _, fold := range []Fold{FoldASCII, FoldUnicode} {
+		opts := DefaultOptions()
+		opts.Fold = fold
+		opts.WholeWords = CustomWords
+		opts.WordBytes = "Xy"
+		for _, algo := range algorithms {
+			opts.Algorithm = algo
+			m, err := CompileWithOptions("AB", opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.Format(m.FindBytes([]byte("xab ab Yab abY ab"))); got != "1:4,1:15" {
+				t.Errorf("%v, fold %d => %q, want %q", algo, fold, got, "1:4,1:15")
+			}
+		}
+	}
+}
+
+func TestWholeWords_bad(t *testing.T) {
+	opts := DefaultOptions()
+	opts.WholeWords = CustomWords
+	if _, err := CompileWithOptions("aa", opts); err == nil {
+		t.Error("some kind of error should be returned")
+	}
+
+	opts.WholeWords = CustomWords + 1
+	if _, err := CompileWithOptions("aa", opts); err == nil {
+		t.Error("some kind of error should be returned")
+	}
+}